@@ -2,6 +2,7 @@
 package agent
 
 import (
+	"context"
 	"time"
 
 	"tkview/internal/organisation"
@@ -21,5 +22,5 @@ type Agent struct {
 
 // Lister should return agents from a datasource.
 type Lister interface {
-	ListAgents(organisationID organisation.ID) ([]Agent, error)
+	ListAgents(ctx context.Context, organisationID organisation.ID) ([]Agent, error)
 }
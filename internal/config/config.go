@@ -0,0 +1,158 @@
+// Package config provides loading of the tkview configuration file and the
+// named connection profiles held within it.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"tkview/internal/token"
+)
+
+// Config is the contents of a tkview configuration file.
+type Config struct {
+	// DefaultProfile is the name of the profile to use when none is requested.
+	DefaultProfile string    `json:"defaultProfile"`
+	Profiles       []Profile `json:"profiles"`
+}
+
+// Profile is a named set of connection details for a testkube instance.
+type Profile struct {
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+	Token Token  `json:"token"`
+	// Organisation and Environment are the names, or IDs, of the environment to select on start.
+	Organisation    string   `json:"organisation"`
+	Environment     string   `json:"environment"`
+	RefreshInterval Duration `json:"refreshInterval"`
+	// Retry overrides how failed requests are retried, it is optional.
+	Retry *Retry `json:"retry"`
+}
+
+// Retry describes how failed requests are retried.
+// Any fields that are not set keep their default values.
+type Retry struct {
+	// MaxAttempts is the most times a request is made, 1 disables retries.
+	MaxAttempts int      `json:"maxAttempts"`
+	BaseDelay   Duration `json:"baseDelay"`
+	MaxDelay    Duration `json:"maxDelay"`
+}
+
+// Token describes where the API token for a profile can be found.
+// Only one of the fields should be set.
+type Token struct {
+	// Value is the token itself.
+	Value string `json:"value"`
+	// Env is the name of an environment variable holding the token.
+	Env string `json:"env"`
+	// File is the path of a file holding the token.
+	File string `json:"file"`
+	// Keyring is an entry in the OS keyring holding the token.
+	Keyring *Keyring `json:"keyring"`
+}
+
+// Keyring identifies an entry in the OS keyring.
+type Keyring struct {
+	Service string `json:"service"`
+	Account string `json:"account"`
+	// Command is a secret-tool compatible command used to look up the entry, it is optional.
+	Command string `json:"command"`
+}
+
+// Duration is a time.Duration that is represented in configuration as a string, such as "30s".
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string, such as "30s".
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("decode duration: %w", err)
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("parse duration %q: %w", s, err)
+	}
+
+	*d = Duration(parsed)
+
+	return nil
+}
+
+var (
+	errProfileNotFound = errors.New("profile not found")
+	errNoToken         = errors.New("no token configured")
+)
+
+// DefaultPath returns the location of the configuration file within the user's
+// configuration directory, which honours XDG_CONFIG_HOME where appropriate.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("find user config dir: %w", err)
+	}
+
+	return filepath.Join(dir, "tkview", "config.json"), nil
+}
+
+// Load reads the configuration file at the passed path.
+// A missing file is not an error, instead the empty configuration is returned.
+func Load(path string) (Config, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Config{}, nil
+	}
+
+	if err != nil {
+		return Config{}, fmt.Errorf("read config %q: %w", path, err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return Config{}, fmt.Errorf("decode config %q: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Profile returns the profile with the passed name.
+// If name is empty, then the default profile is returned instead.
+func (c Config) Profile(name string) (Profile, error) {
+	if name == "" {
+		name = c.DefaultProfile
+	}
+
+	for _, p := range c.Profiles {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+
+	return Profile{}, fmt.Errorf("profile %q: %w", name, errProfileNotFound)
+}
+
+// Source returns the source from which the token can be found.
+func (t Token) Source() (token.Source, error) {
+	switch {
+	case t.Value != "":
+		return token.Static(t.Value), nil
+	case t.Env != "":
+		return token.Env(t.Env), nil
+	case t.File != "":
+		return token.File(t.File), nil
+	case t.Keyring != nil:
+		// Looking up the keyring is relatively slow, so only do it once.
+		return token.Cache(token.Keyring{
+			Service: t.Keyring.Service,
+			Account: t.Keyring.Account,
+			Command: t.Keyring.Command,
+		}), nil
+	default:
+		return nil, errNoToken
+	}
+}
@@ -2,6 +2,8 @@
 package environment
 
 import (
+	"context"
+
 	"tkview/internal/organisation"
 )
 
@@ -16,5 +18,5 @@ type Environment struct {
 
 // Lister should return environments from a datasource.
 type Lister interface {
-	ListEnvironments(organisationID organisation.ID) ([]Environment, error)
+	ListEnvironments(ctx context.Context, organisationID organisation.ID) ([]Environment, error)
 }
@@ -0,0 +1,654 @@
+// Package fake provides an in-process fake of the testkube control plane and agent APIs.
+// It serves organisations, environments, agents, workflows, and executions described by fixture files,
+// so that tkview can be used, and its client exercised, without a real testkube instance.
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubeshop/testkube/pkg/api/v1/testkube"
+)
+
+const (
+	// runTime is how long executions started by the fake take to pass.
+	runTime = 20 * time.Second
+	// logInterval is the delay between each line of a running execution's log being sent.
+	logInterval = 250 * time.Millisecond
+	// shutdownTimeout is how long the server waits for outstanding requests when stopped.
+	shutdownTimeout = 5 * time.Second
+)
+
+// Server is a fake testkube API.
+// The default server cannot be used safely.
+// Instead, initialise a server using New().
+type Server struct {
+	mux *http.ServeMux
+
+	mu   sync.Mutex
+	orgs []fixtureOrganisation
+}
+
+var _ http.Handler = &Server{}
+
+// New creates a fake testkube API serving the fixture files found at the top level of fsys.
+// Each fixture file is a JSON description of an organisation; see Demo() for examples.
+func New(fsys fs.FS) (*Server, error) {
+	orgs, err := loadFixtures(fsys, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		mux:  http.NewServeMux(),
+		orgs: orgs,
+	}
+
+	const envPath = "/organizations/{org}/environments/{env}/agent"
+
+	s.mux.HandleFunc("GET /organizations", s.listOrganisations)
+	s.mux.HandleFunc("GET /organizations/{org}/environments", s.listEnvironments)
+	s.mux.HandleFunc("GET /organizations/{org}/agents", s.listAgents)
+	s.mux.HandleFunc("GET "+envPath+"/test-workflow-with-executions", s.listWorkflows)
+	s.mux.HandleFunc("GET "+envPath+"/test-workflows/{workflow}/executions", s.listExecutions)
+	s.mux.HandleFunc("POST "+envPath+"/test-workflows/{workflow}/executions", s.startExecution)
+	s.mux.HandleFunc("GET "+envPath+"/test-workflows/{workflow}/metrics", s.getMetrics)
+	s.mux.HandleFunc("GET "+envPath+"/test-workflow-executions/{execution}", s.getExecution)
+	s.mux.HandleFunc("POST "+envPath+"/test-workflow-executions/{execution}/abort", s.abortExecution)
+	s.mux.HandleFunc("GET "+envPath+"/test-workflow-executions/{execution}/logs", s.getLogs)
+	s.mux.HandleFunc("GET "+envPath+"/test-workflow-executions/{execution}/notifications", s.streamNotifications)
+
+	return s, nil
+}
+
+// Start serves the passed handler on a random local port, and returns the base url at which it can be reached.
+// The returned function stops the server.
+func Start(h http.Handler) (string, func() error, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, fmt.Errorf("listen: %w", err)
+	}
+
+	srv := &http.Server{
+		Handler:           h,
+		ReadHeaderTimeout: shutdownTimeout,
+	}
+
+	go func() {
+		// The server can only stop by being closed, so there is nothing useful to do with this error.
+		_ = srv.Serve(l)
+	}()
+
+	stop := func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := srv.Shutdown(ctx); err != nil {
+			return fmt.Errorf("shutdown: %w", err)
+		}
+
+		return nil
+	}
+
+	return "http://" + l.Addr().String(), stop, nil
+}
+
+// ServeHTTP serves the testkube API, requiring only that some bearer token is provided.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		http.Error(w, "missing bearer token", http.StatusUnauthorized)
+		return
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// list is how the testkube cloud API wraps lists of things.
+type list[T any] struct {
+	Elements []T `json:"elements"`
+}
+
+// named is how the testkube cloud API encodes organisations and environments.
+type named struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+var (
+	errNotFound   = errors.New("not found")
+	errNotRunning = errors.New("execution is not running")
+)
+
+func (s *Server) listOrganisations(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ret := list[named]{Elements: make([]named, 0, len(s.orgs))}
+	for _, o := range s.orgs {
+		ret.Elements = append(ret.Elements, named{ID: o.ID, Name: o.Name})
+	}
+
+	writeJSON(w, http.StatusOK, ret)
+}
+
+func (s *Server) listEnvironments(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	org, err := s.organisation(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	ret := list[named]{Elements: make([]named, 0, len(org.Environments))}
+	for _, e := range org.Environments {
+		ret.Elements = append(ret.Elements, named{ID: e.ID, Name: e.Name})
+	}
+
+	writeJSON(w, http.StatusOK, ret)
+}
+
+func (s *Server) listAgents(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	org, err := s.organisation(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list[fixtureAgent]{Elements: org.Agents})
+}
+
+func (s *Server) listWorkflows(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	env, err := s.environment(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	ret := make([]testkube.TestWorkflowWithExecutionSummary, 0, len(env.Workflows))
+
+	for _, wf := range env.Workflows {
+		summary := testkube.TestWorkflowWithExecutionSummary{
+			Workflow: &testkube.TestWorkflow{Name: wf.Name, Labels: wf.Labels},
+		}
+
+		if latest := wf.latest(); latest != nil {
+			latestSummary := summarise(*latest)
+			summary.LatestExecution = &latestSummary
+		}
+
+		ret = append(ret, summary)
+	}
+
+	writeJSON(w, http.StatusOK, paginate(r, ret))
+}
+
+func (s *Server) listExecutions(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wf, err := s.workflow(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	ret := testkube.TestWorkflowExecutionsResult{
+		Totals:  &testkube.ExecutionsTotals{Results: int32(len(wf.Executions))}, //nolint:gosec // Fixtures are nowhere near this large.
+		Results: make([]testkube.TestWorkflowExecutionSummary, 0, len(wf.Executions)),
+	}
+
+	for _, e := range wf.Executions {
+		ret.Results = append(ret.Results, summarise(e))
+	}
+
+	// Like testkube, the most recent executions come first.
+	slices.SortFunc(ret.Results, func(a, b testkube.TestWorkflowExecutionSummary) int {
+		return b.ScheduledAt.Compare(a.ScheduledAt)
+	})
+
+	ret.Results = paginate(r, ret.Results)
+
+	writeJSON(w, http.StatusOK, ret)
+}
+
+// getMetrics summarises the finished executions of the workflow.
+func (s *Server) getMetrics(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wf, err := s.workflow(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	var ret testkube.ExecutionsMetrics
+
+	for _, e := range wf.Executions {
+		if e.Result == nil || e.Result.Status == nil || e.Result.FinishedAt.IsZero() {
+			continue
+		}
+
+		ret.TotalExecutions++
+		if *e.Result.Status == testkube.FAILED_TestWorkflowStatus {
+			ret.FailedExecutions++
+		}
+
+		ret.Executions = append(ret.Executions, testkube.ExecutionsMetricsExecutions{
+			ExecutionId: e.Id,
+			Name:        e.Name,
+			Status:      string(*e.Result.Status),
+			Duration:    e.Result.Duration,
+			DurationMs:  e.Result.DurationMs,
+			StartTime:   e.Result.StartedAt,
+		})
+	}
+
+	if ret.TotalExecutions > 0 {
+		ret.PassFailRatio = 100 * float64(ret.TotalExecutions-ret.FailedExecutions) / float64(ret.TotalExecutions)
+	}
+
+	writeJSON(w, http.StatusOK, ret)
+}
+
+// startExecution starts a new execution, which is a copy of the latest execution of the workflow.
+// It runs for runTime, and then passes.
+func (s *Server) startExecution(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wf, err := s.workflow(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	now := time.Now()
+
+	var number int32
+
+	var template fixtureExecution
+
+	if latest := wf.latest(); latest != nil {
+		template = *latest
+	}
+
+	for _, e := range wf.Executions {
+		number = max(number, e.Number)
+	}
+
+	number++
+
+	running := testkube.RUNNING_TestWorkflowStatus
+	steps := make(map[string]testkube.TestWorkflowStepResult)
+
+	for _, ref := range refs(template.Signature) {
+		status := testkube.RUNNING_TestWorkflowStepStatus
+		steps[ref] = testkube.TestWorkflowStepResult{
+			Status:    &status,
+			QueuedAt:  now,
+			StartedAt: now,
+		}
+	}
+
+	e := fixtureExecution{
+		TestWorkflowExecution: testkube.TestWorkflowExecution{
+			Id:          fmt.Sprintf("%s-%d-%d", wf.Name, number, now.UnixNano()),
+			RunnerId:    template.RunnerId,
+			Name:        fmt.Sprintf("%s-%d", wf.Name, number),
+			Number:      number,
+			ScheduledAt: now,
+			StatusAt:    now,
+			Signature:   template.Signature,
+			Result: &testkube.TestWorkflowResult{
+				Status:    &running,
+				QueuedAt:  now,
+				StartedAt: now,
+				Steps:     steps,
+			},
+			Workflow:     &testkube.TestWorkflow{Name: wf.Name, Labels: wf.Labels},
+			ConfigParams: template.ConfigParams,
+			RunningContext: &testkube.TestWorkflowRunningContext{
+				Interface: &testkube.TestWorkflowRunningContextInterface{Type_: ptr(testkube.CLI_TestWorkflowRunningContextInterfaceType)},
+				Actor:     &testkube.TestWorkflowRunningContextActor{Type_: ptr(testkube.USER_TestWorkflowRunningContextActorType)},
+			},
+		},
+		Logs:     template.Logs,
+		finishAt: now.Add(runTime),
+	}
+
+	wf.Executions = append(wf.Executions, e)
+
+	writeJSON(w, http.StatusOK, e.TestWorkflowExecution)
+}
+
+func (s *Server) getExecution(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, err := s.execution(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, e.TestWorkflowExecution)
+}
+
+func (s *Server) abortExecution(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, err := s.execution(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if !e.running() {
+		http.Error(w, errNotRunning.Error(), http.StatusBadRequest)
+		return
+	}
+
+	e.finish(time.Now(), testkube.ABORTED_TestWorkflowStatus, testkube.ABORTED_TestWorkflowStepStatus)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getLogs writes the whole log of an execution as plain text.
+// Like testkube, the start of each step's output is marked by a start hint.
+func (s *Server) getLogs(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, err := s.execution(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+
+	ref := ""
+
+	for _, line := range e.Logs {
+		if line.Ref != ref {
+			ref = line.Ref
+
+			// Too late to handle this error, the client has most likely gone away.
+			_, _ = fmt.Fprintf(w, "%s\n", startHint(ref))
+		}
+
+		_, _ = fmt.Fprintln(w, line.Text)
+	}
+}
+
+// startHint is the instruction testkube logs as a step starts.
+func startHint(ref string) string {
+	return "\u0001\u0005\u0006" + ref + "\u0003start"
+}
+
+// streamNotifications sends the log of an execution as server sent events, a line at a time,
+// as if the execution were producing it.
+func (s *Server) streamNotifications(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+
+	e, err := s.execution(r)
+	if err != nil {
+		s.mu.Unlock()
+		http.Error(w, err.Error(), http.StatusNotFound)
+
+		return
+	}
+
+	// Do not hold the lock whilst streaming, it could take a while.
+	lines := slices.Clone(e.Logs)
+
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+
+	for _, line := range lines {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(logInterval):
+		}
+
+		b, err := json.Marshal(testkube.TestWorkflowExecutionNotification{
+			Ts:  time.Now(),
+			Ref: line.Ref,
+			Log: line.Text + "\n",
+		})
+		if err != nil {
+			return
+		}
+
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", b); err != nil {
+			return
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// organisation finds the organisation of the request.
+// The lock must be held.
+func (s *Server) organisation(r *http.Request) (*fixtureOrganisation, error) {
+	id := r.PathValue("org")
+
+	for i := range s.orgs {
+		if s.orgs[i].ID == id {
+			return &s.orgs[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("organisation %q: %w", id, errNotFound)
+}
+
+// environment finds the environment of the request.
+// The lock must be held.
+func (s *Server) environment(r *http.Request) (*fixtureEnvironment, error) {
+	org, err := s.organisation(r)
+	if err != nil {
+		return nil, err
+	}
+
+	id := r.PathValue("env")
+
+	for i := range org.Environments {
+		if org.Environments[i].ID == id {
+			return &org.Environments[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("environment %q: %w", id, errNotFound)
+}
+
+// workflow finds the workflow of the request, and brings its executions up to date.
+// The lock must be held.
+func (s *Server) workflow(r *http.Request) (*fixtureWorkflow, error) {
+	env, err := s.environment(r)
+	if err != nil {
+		return nil, err
+	}
+
+	name := r.PathValue("workflow")
+
+	for i := range env.Workflows {
+		if env.Workflows[i].Name == name {
+			env.Workflows[i].advance(time.Now())
+			return &env.Workflows[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("workflow %q: %w", name, errNotFound)
+}
+
+// execution finds the execution of the request, and brings it up to date.
+// The lock must be held.
+func (s *Server) execution(r *http.Request) (*fixtureExecution, error) {
+	env, err := s.environment(r)
+	if err != nil {
+		return nil, err
+	}
+
+	id := r.PathValue("execution")
+
+	for i := range env.Workflows {
+		for j := range env.Workflows[i].Executions {
+			if e := &env.Workflows[i].Executions[j]; e.Id == id {
+				e.advance(time.Now())
+				return e, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("execution %q: %w", id, errNotFound)
+}
+
+// latest returns the most recently scheduled execution of the workflow, if there is one.
+func (w *fixtureWorkflow) latest() *fixtureExecution {
+	var latest *fixtureExecution
+
+	for i := range w.Executions {
+		if latest == nil || w.Executions[i].ScheduledAt.After(latest.ScheduledAt) {
+			latest = &w.Executions[i]
+		}
+	}
+
+	return latest
+}
+
+// advance passes every execution of the workflow that should have finished by now.
+func (w *fixtureWorkflow) advance(now time.Time) {
+	for i := range w.Executions {
+		w.Executions[i].advance(now)
+	}
+}
+
+// advance passes the execution if it should have finished by now.
+func (e *fixtureExecution) advance(now time.Time) {
+	if e.running() && !e.finishAt.IsZero() && now.After(e.finishAt) {
+		e.finish(e.finishAt, testkube.PASSED_TestWorkflowStatus, testkube.PASSED_TestWorkflowStepStatus)
+	}
+}
+
+func (e *fixtureExecution) running() bool {
+	return e.Result != nil && e.Result.FinishedAt.IsZero()
+}
+
+// finish marks the execution, and all of its unfinished steps, with the passed statuses.
+func (e *fixtureExecution) finish(at time.Time, status testkube.TestWorkflowStatus, stepStatus testkube.TestWorkflowStepStatus) {
+	e.StatusAt = at
+	e.Result.Status = &status
+	e.Result.FinishedAt = at
+	e.Result.Duration = at.Sub(e.Result.StartedAt).Round(time.Millisecond).String()
+	e.Result.DurationMs = int32(at.Sub(e.Result.StartedAt).Milliseconds()) //nolint:gosec // Executions do not run for weeks.
+
+	for ref, step := range e.Result.Steps {
+		if !step.FinishedAt.IsZero() {
+			continue
+		}
+
+		step.Status = &stepStatus
+		step.FinishedAt = at
+		e.Result.Steps[ref] = step
+	}
+}
+
+// summarise converts an execution to the summary of it that lists contain.
+func summarise(e fixtureExecution) testkube.TestWorkflowExecutionSummary {
+	summary := testkube.TestWorkflowExecutionSummary{
+		Id:             e.Id,
+		RunnerId:       e.RunnerId,
+		Name:           e.Name,
+		Number:         e.Number,
+		ScheduledAt:    e.ScheduledAt,
+		StatusAt:       e.StatusAt,
+		Tags:           e.Tags,
+		RunningContext: e.RunningContext,
+		ConfigParams:   e.ConfigParams,
+	}
+
+	if e.Workflow != nil {
+		summary.Workflow = &testkube.TestWorkflowSummary{Name: e.Workflow.Name}
+	}
+
+	if e.Result != nil {
+		summary.Result = &testkube.TestWorkflowResultSummary{
+			Status:     e.Result.Status,
+			QueuedAt:   e.Result.QueuedAt,
+			StartedAt:  e.Result.StartedAt,
+			FinishedAt: e.Result.FinishedAt,
+			Duration:   e.Result.Duration,
+			DurationMs: e.Result.DurationMs,
+		}
+	}
+
+	return summary
+}
+
+// refs returns the refs of every step in the signature, including nested steps.
+func refs(signature []testkube.TestWorkflowSignature) []string {
+	var ret []string
+
+	for _, sig := range signature {
+		ret = append(ret, sig.Ref)
+		ret = append(ret, refs(sig.Children)...)
+	}
+
+	return ret
+}
+
+// paginate returns the page of items asked for by the page and pageSize query parameters of the request.
+// Like testkube, pages count from 0, and without a page size everything is returned.
+func paginate[T any](r *http.Request, items []T) []T {
+	size, err := strconv.Atoi(r.URL.Query().Get("pageSize"))
+	if err != nil || size <= 0 {
+		return items
+	}
+
+	// A missing, or broken, page number is the first page.
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+
+	start := min(max(page, 0)*size, len(items))
+	end := min(start+size, len(items))
+
+	return items[start:end]
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	// Too late to handle this error, the client has most likely gone away.
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,161 @@
+package fake
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/kubeshop/testkube/pkg/api/v1/testkube"
+)
+
+//go:embed demo
+var demo embed.FS
+
+// Demo returns the fixtures used to demonstrate tkview.
+func Demo() fs.FS {
+	sub, err := fs.Sub(demo, "demo")
+	if err != nil {
+		// The directory is embedded, so this cannot happen.
+		panic(err)
+	}
+
+	return sub
+}
+
+var errNoFixtures = errors.New("no fixture files found")
+
+// fixtureOrganisation is the contents of a fixture file, each of which describes a single organisation.
+type fixtureOrganisation struct {
+	ID           string               `json:"id"`
+	Name         string               `json:"name"`
+	Agents       []fixtureAgent       `json:"agents"`
+	Environments []fixtureEnvironment `json:"environments"`
+}
+
+// fixtureAgent is encoded in the same way as the testkube cloud API agents.
+type fixtureAgent struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`
+	Version    string    `json:"version"`
+	AccessedAt time.Time `json:"accessedAt"`
+}
+
+type fixtureEnvironment struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Workflows []fixtureWorkflow `json:"workflows"`
+}
+
+type fixtureWorkflow struct {
+	Name       string             `json:"name"`
+	Labels     map[string]string  `json:"labels"`
+	Executions []fixtureExecution `json:"executions"`
+}
+
+// fixtureExecution is a testkube execution, along with the log output it produced.
+type fixtureExecution struct {
+	testkube.TestWorkflowExecution
+
+	Logs []fixtureLogLine `json:"logs"`
+
+	// finishAt is when an execution started by the fake will pass, executions from fixtures never finish by themselves.
+	finishAt time.Time
+}
+
+type fixtureLogLine struct {
+	// Ref is the step that output the line.
+	Ref  string `json:"ref"`
+	Text string `json:"text"`
+}
+
+// loadFixtures reads every JSON file at the top level of fsys as an organisation.
+// All times are moved, by the same amount, so that the most recent is now.
+// This keeps the fixtures looking fresh, no matter how old they are.
+func loadFixtures(fsys fs.FS, now time.Time) ([]fixtureOrganisation, error) {
+	paths, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, fmt.Errorf("find fixture files: %w", err)
+	}
+
+	if len(paths) == 0 {
+		return nil, errNoFixtures
+	}
+
+	orgs := make([]fixtureOrganisation, 0, len(paths))
+
+	for _, path := range paths {
+		b, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return nil, fmt.Errorf("read fixture %q: %w", path, err)
+		}
+
+		var org fixtureOrganisation
+		if err := json.Unmarshal(b, &org); err != nil {
+			return nil, fmt.Errorf("decode fixture %q: %w", path, err)
+		}
+
+		orgs = append(orgs, org)
+	}
+
+	var latest time.Time
+
+	for i := range orgs {
+		orgs[i].eachTime(func(t *time.Time) {
+			if t.After(latest) {
+				latest = *t
+			}
+		})
+	}
+
+	offset := now.Sub(latest)
+
+	for i := range orgs {
+		orgs[i].eachTime(func(t *time.Time) {
+			if !t.IsZero() {
+				*t = t.Add(offset)
+			}
+		})
+	}
+
+	return orgs, nil
+}
+
+// eachTime calls fn with every time held within the organisation, so that they can be altered.
+func (o *fixtureOrganisation) eachTime(fn func(t *time.Time)) {
+	for i := range o.Agents {
+		fn(&o.Agents[i].AccessedAt)
+	}
+
+	for i := range o.Environments {
+		for j := range o.Environments[i].Workflows {
+			for k := range o.Environments[i].Workflows[j].Executions {
+				o.Environments[i].Workflows[j].Executions[k].eachTime(fn)
+			}
+		}
+	}
+}
+
+func (e *fixtureExecution) eachTime(fn func(t *time.Time)) {
+	fn(&e.ScheduledAt)
+	fn(&e.StatusAt)
+
+	if e.Result == nil {
+		return
+	}
+
+	fn(&e.Result.QueuedAt)
+	fn(&e.Result.StartedAt)
+	fn(&e.Result.FinishedAt)
+
+	for ref, step := range e.Result.Steps {
+		fn(&step.QueuedAt)
+		fn(&step.StartedAt)
+		fn(&step.FinishedAt)
+
+		e.Result.Steps[ref] = step
+	}
+}
@@ -0,0 +1,401 @@
+// Package list prints the organisations, environments, agents, workflows, and executions seen through a TKView,
+// as a table, JSON, or YAML, so that they can be scripted against without the UI.
+package list
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"tkview/internal/environment"
+	"tkview/internal/tkview"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Commands are the names of the things that can be listed.
+var Commands = []string{"orgs", "envs", "agents", "workflows", "executions"}
+
+// Format is how the list is printed.
+type Format string
+
+const (
+	// FormatTable prints a table with a header row, aligned for reading, the default.
+	FormatTable Format = "table"
+	// FormatJSON prints an indented JSON array, with times in RFC 3339 and anything unknown left out.
+	FormatJSON Format = "json"
+	// FormatYAML prints a YAML sequence, with the same fields as FormatJSON.
+	FormatYAML Format = "yaml"
+)
+
+// Selection is what to list within. Each part may be either a name or an ID, and is optional
+// so long as there is only one thing it could be.
+type Selection struct {
+	Organisation string
+	Environment  string
+	Workflow     string
+}
+
+var (
+	errNoCommand      = errors.New("no command given")
+	errUnknownCommand = errors.New("unknown command")
+	errUnknownFormat  = errors.New("unknown output format, expected table, json, or yaml")
+	errNotFound       = errors.New("not found")
+	errAmbiguous      = errors.New("more than one match")
+)
+
+// Run runs the list command named by the first of args, with the rest of args as its flags.
+// Whatever was selected by the profile is passed as defaults, which the flags take precedence over.
+// Organisations whose environments failed to load are reported as an error, after listing everything else,
+// unless what was listed could not have come from them, see relevantOrganisations.
+func Run(ctx context.Context, w, errW io.Writer, tk *tkview.TKView, args []string, defaults Selection) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w, expected one of %s", errNoCommand, strings.Join(Commands, ", "))
+	}
+
+	command := args[0]
+	if !slices.Contains(Commands, command) {
+		return fmt.Errorf("%w %q, expected one of %s", errUnknownCommand, command, strings.Join(Commands, ", "))
+	}
+
+	sel := defaults
+	format := string(FormatTable)
+
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	fs.SetOutput(errW)
+	fs.StringVar(&sel.Organisation, "org", sel.Organisation, "Organisation name or ID")
+	fs.StringVar(&sel.Environment, "env", sel.Environment, "Environment name or ID")
+	fs.StringVar(&format, "output", format, "Output format, one of table, json, or yaml")
+	fs.StringVar(&format, "o", format, "Shorthand for -output")
+
+	if command == "executions" {
+		fs.StringVar(&sel.Workflow, "workflow", "", "Workflow name or ID")
+	}
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return fmt.Errorf("%s: %w", command, err)
+	}
+
+	f := Format(format)
+	if f != FormatTable && f != FormatJSON && f != FormatYAML {
+		return fmt.Errorf("%q: %w", format, errUnknownFormat)
+	}
+
+	orgs, err := tk.GetOrganisationTree(ctx)
+	if err != nil {
+		return err //nolint:wrapcheck // TKView errors already say what failed.
+	}
+
+	var listErr error
+
+	switch command {
+	case "orgs":
+		listErr = write(w, f, organisations(orgs))
+	case "envs":
+		listErr = listEnvironments(w, f, orgs, sel)
+	case "agents":
+		listErr = listAgents(ctx, w, f, tk, orgs, sel)
+	case "workflows":
+		listErr = listWorkflows(ctx, w, f, tk, orgs, sel)
+	case "executions":
+		listErr = listExecutions(ctx, w, f, tk, orgs, sel)
+	}
+
+	// Anything that failed to load may be why something was not found, so is reported either way.
+	errs := []error{listErr}
+
+	for _, org := range relevantOrganisations(command, tk, orgs, sel) {
+		errs = append(errs, org.Err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// relevantOrganisations returns the organisations that the command may have listed from.
+// Until an environment is found, any organisation whose environments failed to load may have held it, so all are.
+func relevantOrganisations(command string, tk *tkview.TKView, orgs []tkview.Organisation, sel Selection) []tkview.Organisation {
+	// Organisations are listed whether or not their environments loaded.
+	if command == "orgs" {
+		return nil
+	}
+
+	if sel.Organisation != "" {
+		if org, err := findOrganisation(orgs, sel.Organisation); err == nil {
+			return []tkview.Organisation{org}
+		}
+	}
+
+	if env, err := tk.GetCurrentEnvironment(); err == nil && command != "envs" {
+		for _, org := range orgs {
+			if slices.ContainsFunc(org.Envs, func(e environment.Environment) bool { return e.ID == env.ID }) {
+				return []tkview.Organisation{org}
+			}
+		}
+	}
+
+	return orgs
+}
+
+func listEnvironments(w io.Writer, f Format, orgs []tkview.Organisation, sel Selection) error {
+	if sel.Organisation != "" {
+		org, err := findOrganisation(orgs, sel.Organisation)
+		if err != nil {
+			return err
+		}
+
+		orgs = []tkview.Organisation{org}
+	}
+
+	return write(w, f, environments(orgs))
+}
+
+func listAgents(ctx context.Context, w io.Writer, f Format, tk *tkview.TKView, orgs []tkview.Organisation, sel Selection) error {
+	// Agents belong to the organisation, but are only found through one of its environments.
+	if sel.Environment == "" {
+		org, err := onlyOrganisation(orgs, sel.Organisation)
+		if err != nil {
+			return err
+		}
+
+		if len(org.Envs) == 0 {
+			return fmt.Errorf("organisation %q has no environments to find its agents through: %w", org.Name, errNotFound)
+		}
+
+		sel.Environment = string(org.Envs[0].ID)
+	}
+
+	if err := selectEnvironment(tk, orgs, sel); err != nil {
+		return err
+	}
+
+	agents, err := tk.GetAgents(ctx)
+	if err != nil {
+		return err //nolint:wrapcheck // TKView errors already say what failed.
+	}
+
+	return write(w, f, agentRecords(agents))
+}
+
+func listWorkflows(ctx context.Context, w io.Writer, f Format, tk *tkview.TKView, orgs []tkview.Organisation, sel Selection) error {
+	if err := selectEnvironment(tk, orgs, sel); err != nil {
+		return err
+	}
+
+	workflows, err := tk.GetWorkflowTree(ctx)
+	if err != nil {
+		return err //nolint:wrapcheck // TKView errors already say what failed.
+	}
+
+	return write(w, f, workflowRecords(workflows))
+}
+
+func listExecutions(ctx context.Context, w io.Writer, f Format, tk *tkview.TKView, orgs []tkview.Organisation, sel Selection) error {
+	if err := selectEnvironment(tk, orgs, sel); err != nil {
+		return err
+	}
+
+	workflows, err := tk.GetWorkflowTree(ctx)
+	if err != nil {
+		return err //nolint:wrapcheck // TKView errors already say what failed.
+	}
+
+	var matches []tkview.Workflow
+
+	for _, wf := range workflows {
+		if sel.Workflow == "" || sel.Workflow == wf.Name || sel.Workflow == string(wf.ID) {
+			matches = append(matches, wf)
+		}
+	}
+
+	wf, err := only(matches, "workflow", sel.Workflow, "-workflow")
+	if err != nil {
+		return err
+	}
+
+	if err := tk.SelectWorkflow(ctx, wf.ID); err != nil {
+		return err //nolint:wrapcheck // TKView errors already say what failed.
+	}
+
+	wf, err = tk.GetCurrentWorkflow()
+	if err != nil {
+		return err //nolint:wrapcheck // TKView errors already say what failed.
+	}
+
+	return write(w, f, executionRecords(wf.Executions))
+}
+
+// findOrganisation finds the organisation with the passed name or ID.
+func findOrganisation(orgs []tkview.Organisation, nameOrID string) (tkview.Organisation, error) {
+	var matches []tkview.Organisation
+
+	for _, org := range orgs {
+		if nameOrID == org.Name || nameOrID == string(org.ID) {
+			matches = append(matches, org)
+		}
+	}
+
+	return only(matches, "organisation", nameOrID, "-org")
+}
+
+// onlyOrganisation finds the organisation with the passed name or ID, or if it is empty, the only organisation there is.
+func onlyOrganisation(orgs []tkview.Organisation, nameOrID string) (tkview.Organisation, error) {
+	if nameOrID != "" {
+		return findOrganisation(orgs, nameOrID)
+	}
+
+	return only(orgs, "organisation", "", "-org")
+}
+
+// selectEnvironment selects the environment with the selected name or ID, within the selected organisation, if any.
+// If no environment is selected, the only environment there is is selected.
+func selectEnvironment(tk *tkview.TKView, orgs []tkview.Organisation, sel Selection) error {
+	if sel.Organisation != "" {
+		org, err := findOrganisation(orgs, sel.Organisation)
+		if err != nil {
+			return err
+		}
+
+		orgs = []tkview.Organisation{org}
+	}
+
+	var matches []environment.Environment
+
+	for _, org := range orgs {
+		for _, env := range org.Envs {
+			if sel.Environment == "" || sel.Environment == env.Name || sel.Environment == string(env.ID) {
+				matches = append(matches, env)
+			}
+		}
+	}
+
+	env, err := only(matches, "environment", sel.Environment, "-env")
+	if err != nil {
+		return err
+	}
+
+	return tk.SelectEnvironment(env.ID) //nolint:wrapcheck // TKView errors already say what failed.
+}
+
+// only returns the only one of matches, or an error saying which flag to use to choose one.
+func only[T any](matches []T, kind, nameOrID, flagName string) (T, error) {
+	var zero T
+
+	switch {
+	case len(matches) == 1:
+		return matches[0], nil
+	case len(matches) == 0 && nameOrID != "":
+		return zero, fmt.Errorf("%s %q: %w", kind, nameOrID, errNotFound)
+	case len(matches) == 0:
+		return zero, fmt.Errorf("%s: %w", kind, errNotFound)
+	case nameOrID != "":
+		return zero, fmt.Errorf("%s %q: %w, use the ID instead", kind, nameOrID, errAmbiguous)
+	default:
+		return zero, fmt.Errorf("%s: %w, choose one with %s", kind, errAmbiguous, flagName)
+	}
+}
+
+// record is a listed item, which can also be printed as a table row.
+type record interface {
+	header() []string
+	row() []string
+}
+
+// write prints the passed records in the passed format.
+func write[T record](w io.Writer, f Format, records []T) error {
+	switch f {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		// An empty list is still a list, rather than null.
+		if err := enc.Encode(append([]T{}, records...)); err != nil {
+			return fmt.Errorf("encode json: %w", err)
+		}
+
+		return nil
+	case FormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+
+		if err := enc.Encode(append([]T{}, records...)); err != nil {
+			return fmt.Errorf("encode yaml: %w", err)
+		}
+
+		if err := enc.Close(); err != nil {
+			return fmt.Errorf("encode yaml: %w", err)
+		}
+
+		return nil
+	case FormatTable:
+	}
+
+	const padding = 2
+
+	tw := tabwriter.NewWriter(w, 0, 0, padding, ' ', 0)
+
+	var zero T
+
+	lines := [][]string{zero.header()}
+	for _, r := range records {
+		lines = append(lines, r.row())
+	}
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(tw, strings.Join(line, "\t")); err != nil {
+			return fmt.Errorf("write table: %w", err)
+		}
+	}
+
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("write table: %w", err)
+	}
+
+	return nil
+}
+
+// formatTime formats the passed time for a table, leaving it blank if it is unknown.
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+// optionalTime returns the passed time, or nil if it is unknown, so that it is left out rather than shown as year 1.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
+
+// formatLabels formats labels for a table, in a stable order.
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+
+	for _, k := range slices.Sorted(maps.Keys(labels)) {
+		pairs = append(pairs, k+"="+labels[k])
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// formatNumber formats a number for a table, leaving it blank if it is unknown.
+func formatNumber(n int) string {
+	if n == 0 {
+		return ""
+	}
+
+	return strconv.Itoa(n)
+}
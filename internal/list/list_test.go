@@ -0,0 +1,102 @@
+package list_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"tkview/internal/agent"
+	"tkview/internal/environment"
+	"tkview/internal/list"
+	"tkview/internal/organisation"
+	"tkview/internal/tkview"
+	"tkview/internal/workflow"
+)
+
+var errUnreachable = errors.New("unreachable")
+
+// fakeClient serves two organisations, the environments of globex failing to load.
+type fakeClient struct{}
+
+func (fakeClient) ListOrganisations(context.Context) ([]organisation.Organisation, error) {
+	return []organisation.Organisation{{ID: "acme", Name: "Acme"}, {ID: "globex", Name: "Globex"}}, nil
+}
+
+func (fakeClient) ListEnvironments(_ context.Context, orgID organisation.ID) ([]environment.Environment, error) {
+	if orgID == "globex" {
+		return nil, errUnreachable
+	}
+
+	return []environment.Environment{{ID: "prod", Name: "production"}}, nil
+}
+
+func (fakeClient) ListAgents(context.Context, organisation.ID) ([]agent.Agent, error) {
+	return []agent.Agent{{ID: "runner", Name: "runner"}}, nil
+}
+
+func (fakeClient) ListWorkflows(context.Context, organisation.ID, environment.ID) ([]workflow.Workflow, error) {
+	return []workflow.Workflow{{ID: "smoke", Name: "smoke"}}, nil
+}
+
+func (fakeClient) ListExecutions(context.Context, organisation.ID, environment.ID, workflow.ID, workflow.Page) ([]workflow.Execution, error) {
+	return nil, nil
+}
+
+func (fakeClient) ListSteps(context.Context, organisation.ID, environment.ID, workflow.ExecutionID) ([]workflow.Step, error) {
+	return nil, nil
+}
+
+func (fakeClient) GetMetrics(context.Context, organisation.ID, environment.ID, workflow.ID) (workflow.Metrics, error) {
+	return workflow.Metrics{}, nil
+}
+
+func (fakeClient) StartExecution(context.Context, organisation.ID, environment.ID, workflow.ID) (workflow.Execution, error) {
+	return workflow.Execution{}, nil
+}
+
+func (fakeClient) AbortExecution(context.Context, organisation.ID, environment.ID, workflow.ExecutionID) error {
+	return nil
+}
+
+func (fakeClient) StreamLogs(context.Context, organisation.ID, environment.ID, workflow.ExecutionID, chan<- workflow.LogLine) error {
+	return nil
+}
+
+func TestRunReportsRelevantFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{name: "organisations are listed regardless", args: []string{"orgs"}, want: "Globex"},
+		{name: "environments of every organisation", args: []string{"envs"}, want: "production", wantErr: true},
+		{name: "environments of a loaded organisation", args: []string{"envs", "-org", "acme"}, want: "production"},
+		{name: "environments of a failed organisation", args: []string{"envs", "-org", "globex"}, wantErr: true},
+		{name: "agents of a loaded organisation", args: []string{"agents", "-org", "acme"}, want: "runner"},
+		{name: "workflows of a found environment", args: []string{"workflows", "-env", "production"}, want: "smoke"},
+		{name: "workflows of an environment that may be in a failed organisation", args: []string{"workflows", "-env", "dev"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+
+			err := list.Run(context.Background(), &out, io.Discard, tkview.New(fakeClient{}), tt.args, list.Selection{})
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run(%q) error = %v, want error %t", tt.args, err, tt.wantErr)
+			}
+
+			if tt.wantErr && !errors.Is(err, errUnreachable) {
+				t.Errorf("Run(%q) error = %v, want it to include %v", tt.args, err, errUnreachable)
+			}
+
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("Run(%q) printed:\n%s\nwant it to include %q", tt.args, out.String(), tt.want)
+			}
+		})
+	}
+}
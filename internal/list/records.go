@@ -0,0 +1,173 @@
+package list
+
+import (
+	"time"
+
+	"tkview/internal/agent"
+	"tkview/internal/tkview"
+)
+
+// The records are what is listed, with stable names for scripts to rely on, and without anything
+// the TKView only keeps for the UI, such as steps.
+
+type organisationRecord struct {
+	ID   string `json:"id"   yaml:"id"`
+	Name string `json:"name" yaml:"name"`
+}
+
+func organisations(orgs []tkview.Organisation) []organisationRecord {
+	records := make([]organisationRecord, 0, len(orgs))
+
+	for _, org := range orgs {
+		records = append(records, organisationRecord{ID: string(org.ID), Name: org.Name})
+	}
+
+	return records
+}
+
+func (organisationRecord) header() []string {
+	return []string{"ID", "NAME"}
+}
+
+func (r organisationRecord) row() []string {
+	return []string{r.ID, r.Name}
+}
+
+type environmentRecord struct {
+	ID           string `json:"id"           yaml:"id"`
+	Name         string `json:"name"         yaml:"name"`
+	Organisation string `json:"organisation" yaml:"organisation"`
+}
+
+func environments(orgs []tkview.Organisation) []environmentRecord {
+	var records []environmentRecord
+
+	for _, org := range orgs {
+		for _, env := range org.Envs {
+			records = append(records, environmentRecord{ID: string(env.ID), Name: env.Name, Organisation: org.Name})
+		}
+	}
+
+	return records
+}
+
+func (environmentRecord) header() []string {
+	return []string{"ID", "NAME", "ORGANISATION"}
+}
+
+func (r environmentRecord) row() []string {
+	return []string{r.ID, r.Name, r.Organisation}
+}
+
+type agentRecord struct {
+	ID       string     `json:"id"                 yaml:"id"`
+	Name     string     `json:"name"               yaml:"name"`
+	Type     string     `json:"type"               yaml:"type"`
+	Version  string     `json:"version"            yaml:"version"`
+	LastSeen *time.Time `json:"lastSeen,omitempty" yaml:"lastSeen,omitempty"`
+}
+
+func agentRecords(agents []agent.Agent) []agentRecord {
+	records := make([]agentRecord, 0, len(agents))
+
+	for _, a := range agents {
+		records = append(records, agentRecord{
+			ID:       string(a.ID),
+			Name:     a.Name,
+			Type:     a.Type,
+			Version:  a.Version,
+			LastSeen: optionalTime(a.LastSeen),
+		})
+	}
+
+	return records
+}
+
+func (agentRecord) header() []string {
+	return []string{"ID", "NAME", "TYPE", "VERSION", "LAST SEEN"}
+}
+
+func (r agentRecord) row() []string {
+	return []string{r.ID, r.Name, r.Type, r.Version, formatTime(r.LastSeen)}
+}
+
+type workflowRecord struct {
+	ID                  string            `json:"id"                            yaml:"id"`
+	Name                string            `json:"name"                          yaml:"name"`
+	LastExecutionStatus string            `json:"lastExecutionStatus,omitempty" yaml:"lastExecutionStatus,omitempty"`
+	LastExecutionAt     *time.Time        `json:"lastExecutionAt,omitempty"     yaml:"lastExecutionAt,omitempty"`
+	Labels              map[string]string `json:"labels,omitempty"              yaml:"labels,omitempty"`
+}
+
+func workflowRecords(workflows []tkview.Workflow) []workflowRecord {
+	records := make([]workflowRecord, 0, len(workflows))
+
+	for _, w := range workflows {
+		records = append(records, workflowRecord{
+			ID:                  string(w.ID),
+			Name:                w.Name,
+			LastExecutionStatus: w.LastExecutionStatus,
+			LastExecutionAt:     optionalTime(w.LastExecutionAt),
+			Labels:              w.Labels,
+		})
+	}
+
+	return records
+}
+
+func (workflowRecord) header() []string {
+	return []string{"NAME", "LAST STATUS", "LAST EXECUTION", "LABELS"}
+}
+
+func (r workflowRecord) row() []string {
+	return []string{r.Name, r.LastExecutionStatus, formatTime(r.LastExecutionAt), formatLabels(r.Labels)}
+}
+
+type executionRecord struct {
+	ID     string `json:"id"               yaml:"id"`
+	Name   string `json:"name"             yaml:"name"`
+	Number int    `json:"number,omitempty" yaml:"number,omitempty"`
+	Status string `json:"status"           yaml:"status"`
+	// FinishedAt and Duration are left out whilst the execution is still running.
+	StartedAt  *time.Time `json:"startedAt,omitempty"  yaml:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty" yaml:"finishedAt,omitempty"`
+	Duration   string     `json:"duration,omitempty"   yaml:"duration,omitempty"`
+	Trigger    string     `json:"trigger,omitempty"    yaml:"trigger,omitempty"`
+	Runner     string     `json:"runner,omitempty"     yaml:"runner,omitempty"`
+}
+
+func executionRecords(executions []tkview.Execution) []executionRecord {
+	records := make([]executionRecord, 0, len(executions))
+
+	for _, e := range executions {
+		r := executionRecord{
+			ID:         string(e.ID),
+			Name:       e.Name,
+			Number:     e.Number,
+			Status:     e.Status,
+			StartedAt:  optionalTime(e.StartedAt),
+			FinishedAt: optionalTime(e.FinishedAt),
+			Trigger:    e.Trigger,
+			Runner:     string(e.RunnerID),
+		}
+
+		if e.Duration != 0 {
+			r.Duration = e.Duration.String()
+		}
+
+		records = append(records, r)
+	}
+
+	return records
+}
+
+func (executionRecord) header() []string {
+	return []string{"ID", "NAME", "NUMBER", "STATUS", "STARTED", "FINISHED", "DURATION", "TRIGGER"}
+}
+
+func (r executionRecord) row() []string {
+	return []string{
+		r.ID, r.Name, formatNumber(r.Number), r.Status,
+		formatTime(r.StartedAt), formatTime(r.FinishedAt), r.Duration, r.Trigger,
+	}
+}
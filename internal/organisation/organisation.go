@@ -1,6 +1,10 @@
 // Package organisation provides an abstraction for organisation data models.
 package organisation
 
+import (
+	"context"
+)
+
 // ID is the unique identifier of an organisation.
 type ID string
 
@@ -12,5 +16,5 @@ type Organisation struct {
 
 // Lister should return organisations from a datasource.
 type Lister interface {
-	ListOrganisations() ([]Organisation, error)
+	ListOrganisations(ctx context.Context) ([]Organisation, error)
 }
@@ -0,0 +1,129 @@
+// Package state provides persistence of what was being looked at when tkview last exited,
+// so that it can carry on from there on the next start.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"tkview/internal/environment"
+	"tkview/internal/organisation"
+	"tkview/internal/workflow"
+)
+
+// Session is what was being looked at in one testkube instance.
+// Anything that was not being looked at is left empty.
+type Session struct {
+	Organisation      organisation.ID `json:"organisation,omitempty"`
+	Environment       environment.ID  `json:"environment,omitempty"`
+	Workflow          workflow.ID     `json:"workflow,omitempty"`
+	ExpandedWorkflows []workflow.ID   `json:"expandedWorkflows,omitempty"`
+	// Focused is the name of the focused pane, and Sort is the name of the order of the workflows.
+	Focused string `json:"focused,omitempty"`
+	Sort    string `json:"sort,omitempty"`
+}
+
+var errCorrupt = errors.New("corrupt state file")
+
+// Store keeps the sessions of every testkube instance in a single file.
+// The file is only read and written when a session is loaded or saved, so multiple tkviews can share it,
+// with the last to save a session winning.
+type Store struct {
+	path string
+}
+
+// NewStore creates a Store that keeps its sessions in the file at the passed path.
+func NewStore(path string) Store {
+	return Store{path: path}
+}
+
+// DefaultPath returns the location of the state file within the user's
+// configuration directory, next to the configuration file.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("find user config dir: %w", err)
+	}
+
+	return filepath.Join(dir, "tkview", "state.json"), nil
+}
+
+// Key identifies the sessions of a testkube instance, as reached through a profile.
+// The same instance reached through different profiles, with different tokens, may see different things.
+func Key(profile, url string) string {
+	if profile == "" {
+		return url
+	}
+
+	return profile + "@" + url
+}
+
+// Load returns the session saved under the passed key.
+// If there is none, or no state file at all, the empty session is returned.
+func (s Store) Load(key string) (Session, error) {
+	sessions, err := s.read()
+	if err != nil {
+		return Session{}, err
+	}
+
+	return sessions[key], nil
+}
+
+// Save saves the passed session under the passed key, replacing any saved before.
+// A state file that cannot be decoded is replaced, as its sessions are lost either way.
+func (s Store) Save(key string, session Session) error {
+	sessions, err := s.read()
+
+	switch {
+	case errors.Is(err, errCorrupt):
+		sessions = make(map[string]Session)
+	case err != nil:
+		return err
+	}
+
+	sessions[key] = session
+
+	b, err := json.MarshalIndent(sessions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("create state dir: %w", err)
+	}
+
+	// Write to a temporary file first, so that a crash part way through does not lose every session.
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return fmt.Errorf("write state %q: %w", tmp, err)
+	}
+
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("replace state %q: %w", s.path, err)
+	}
+
+	return nil
+}
+
+func (s Store) read() (map[string]Session, error) {
+	sessions := make(map[string]Session)
+
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return sessions, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("read state %q: %w", s.path, err)
+	}
+
+	if err := json.Unmarshal(b, &sessions); err != nil {
+		return nil, fmt.Errorf("decode state %q: %w: %w", s.path, errCorrupt, err)
+	}
+
+	return sessions, nil
+}
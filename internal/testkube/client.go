@@ -3,16 +3,20 @@
 package testkube
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"tkview/internal/agent"
 	"tkview/internal/environment"
 	"tkview/internal/organisation"
+	"tkview/internal/token"
 	"tkview/internal/workflow"
 
 	"github.com/kubeshop/testkube/cmd/kubectl-testkube/commands/agents"
@@ -24,36 +28,67 @@ import (
 // The default client cannot be used safely.
 // Instead, initialise a client using New().
 type Client struct {
-	url   string
-	token string
+	url     string
+	token   token.Source
+	retry   RetryPolicy
+	breaker *breaker
 }
 
 var (
-	_ agent.Lister        = Client{}
-	_ environment.Lister  = Client{}
-	_ workflow.Lister     = Client{}
-	_ organisation.Lister = Client{}
+	_ agent.Lister             = Client{}
+	_ environment.Lister       = Client{}
+	_ workflow.ExecutionLister = Client{}
+	_ workflow.Lister          = Client{}
+	_ workflow.Starter         = Client{}
+	_ workflow.Aborter         = Client{}
+	_ workflow.StepLister      = Client{}
+	_ workflow.LogStreamer     = Client{}
+	_ workflow.MetricsGetter   = Client{}
+	_ organisation.Lister      = Client{}
 )
 
 // New creates a valid testkube API client.
 // The passed url should be the base url at which the API can be accessed.
-// The token should be a valid token string for authenticating with the API.
-func New(url, token string) Client {
+// The token source is asked for a valid token string for authenticating with the API
+// each time a request is made.
+// Failed requests are retried using the DefaultRetryPolicy, see WithRetryPolicy to change it.
+func New(url string, token token.Source) Client {
 	return Client{
-		url:   url,
-		token: token,
+		url:     url,
+		token:   token,
+		retry:   DefaultRetryPolicy,
+		breaker: newBreaker(),
 	}
 }
 
+// The paths, and cloudList below, are those of the requests made by the cloud clients in
+// github.com/kubeshop/testkube/pkg/cloud/client, at the version in go.mod, which were used to list these before.
+// NewOrganizationsClient, NewEnvironmentsClient, and NewAgentsClient each set the Path that their List appends
+// to the base url for a GET, and List decodes the response as a ListResponse, with the list under "elements".
+const (
+	listOrganisationPath = "%s/organizations"
+	listEnvironmentPath  = "%s/organizations/%s/environments"
+	listAgentPath        = "%s/organizations/%s/agents"
+)
+
+// cloudList is how the testkube cloud API wraps lists of things, such as organisations.
+// These are read using the cloud types, but through the same requests as everything else,
+// so that they are retried, and fail with an *APIError, in the same way.
+type cloudList[T any] struct {
+	Elements []T `json:"elements"`
+}
+
 // ListOrganisations returns all discoverable organisations using the provided API token.
-func (c Client) ListOrganisations() ([]organisation.Organisation, error) {
-	oo, err := client.NewOrganizationsClient(c.url, c.token).List()
-	if err != nil {
-		return nil, fmt.Errorf("list organisations at %q: %w", c.url, err)
+func (c Client) ListOrganisations(ctx context.Context) ([]organisation.Organisation, error) {
+	url := fmt.Sprintf(listOrganisationPath, c.url)
+
+	var result cloudList[client.Organization]
+	if err := c.callTestKubeAPI(ctx, http.MethodGet, url, nil, &result); err != nil {
+		return nil, fmt.Errorf("call testkube api: %w", err)
 	}
 
-	ret := make([]organisation.Organisation, 0, len(oo))
-	for _, o := range oo {
+	ret := make([]organisation.Organisation, 0, len(result.Elements))
+	for _, o := range result.Elements {
 		ret = append(ret, organisation.Organisation{
 			ID:   organisation.ID(o.Id),
 			Name: o.Name,
@@ -64,14 +99,16 @@ func (c Client) ListOrganisations() ([]organisation.Organisation, error) {
 }
 
 // ListEnvironments returns all discoverable environments under the passed organisation using the provided API token.
-func (c Client) ListEnvironments(organisationID organisation.ID) ([]environment.Environment, error) {
-	ee, err := client.NewEnvironmentsClient(c.url, c.token, string(organisationID)).List()
-	if err != nil {
-		return nil, fmt.Errorf("list environments at %q for %q: %w", c.url, organisationID, err)
+func (c Client) ListEnvironments(ctx context.Context, organisationID organisation.ID) ([]environment.Environment, error) {
+	url := fmt.Sprintf(listEnvironmentPath, c.url, organisationID)
+
+	var result cloudList[client.Environment]
+	if err := c.callTestKubeAPI(ctx, http.MethodGet, url, nil, &result); err != nil {
+		return nil, fmt.Errorf("call testkube api: %w", err)
 	}
 
-	ret := make([]environment.Environment, 0, len(ee))
-	for _, e := range ee {
+	ret := make([]environment.Environment, 0, len(result.Elements))
+	for _, e := range result.Elements {
 		ret = append(ret, environment.Environment{
 			ID:   environment.ID(e.Id),
 			Name: e.Name,
@@ -82,25 +119,33 @@ func (c Client) ListEnvironments(organisationID organisation.ID) ([]environment.
 }
 
 // ListAgents returns all discoverable agents under the passed organisation using the provided API token.
-func (c Client) ListAgents(organisationID organisation.ID) ([]agent.Agent, error) {
-	aa, err := client.NewAgentsClient(c.url, c.token, string(organisationID)).List()
-	if err != nil {
-		return nil, fmt.Errorf("list agents at %q for %q: %w", c.url, organisationID, err)
+func (c Client) ListAgents(ctx context.Context, organisationID organisation.ID) ([]agent.Agent, error) {
+	url := fmt.Sprintf(listAgentPath, c.url, organisationID)
+
+	var result cloudList[client.Agent]
+	if err := c.callTestKubeAPI(ctx, http.MethodGet, url, nil, &result); err != nil {
+		return nil, fmt.Errorf("call testkube api: %w", err)
 	}
 
-	ret := make([]agent.Agent, 0, len(aa))
-	for _, a := range aa {
+	ret := make([]agent.Agent, 0, len(result.Elements))
+	for _, a := range result.Elements {
 		agentType, err := agents.GetCliAgentType(a.Type)
 		if err != nil {
 			agentType = "Unknown"
 		}
 
+		// Agents that have never connected have not been seen.
+		var lastSeen time.Time
+		if a.AccessedAt != nil {
+			lastSeen = *a.AccessedAt
+		}
+
 		ret = append(ret, agent.Agent{
 			ID:       agent.ID(a.ID),
 			Name:     a.Name,
 			Type:     agentType,
 			Version:  a.Version,
-			LastSeen: *a.AccessedAt,
+			LastSeen: lastSeen,
 		})
 	}
 
@@ -108,94 +153,497 @@ func (c Client) ListAgents(organisationID organisation.ID) ([]agent.Agent, error
 }
 
 const (
-	listWorkflowPath  = "%s/organizations/%s/environments/%s/agent/test-workflow-with-executions"
-	listExecutionPath = "%s/organizations/%s/environments/%s/agent/test-workflows/%s/executions"
+	listWorkflowPath   = "%s/organizations/%s/environments/%s/agent/test-workflow-with-executions"
+	listExecutionPath  = "%s/organizations/%s/environments/%s/agent/test-workflows/%s/executions"
+	metricsPath        = "%s/organizations/%s/environments/%s/agent/test-workflows/%s/metrics"
+	startExecutionPath = listExecutionPath
+	abortExecutionPath = "%s/organizations/%s/environments/%s/agent/test-workflow-executions/%s/abort"
+	getExecutionPath   = "%s/organizations/%s/environments/%s/agent/test-workflow-executions/%s"
+	notificationsPath  = "%s/organizations/%s/environments/%s/agent/test-workflow-executions/%s/notifications"
+	logsPath           = "%s/organizations/%s/environments/%s/agent/test-workflow-executions/%s/logs"
+	// pageQuery selects a page, counting from 0, and the page size of a list.
+	pageQuery = "?page=%d&pageSize=%d"
 )
 
+// workflowPageSize is the number of workflows requested at a time.
+const workflowPageSize = 100
+
 // ListWorkflows returns all workflows under the passed organisation and environment.
+// The workflows are read a page at a time, until there are no more.
 // Sadly, all parameters are required due to the testkube API.
-func (c Client) ListWorkflows(orgID organisation.ID, envID environment.ID) ([]workflow.Workflow, error) {
-	url := fmt.Sprintf(listWorkflowPath, c.url, orgID, envID)
+func (c Client) ListWorkflows(ctx context.Context, orgID organisation.ID, envID environment.ID) ([]workflow.Workflow, error) {
+	var ret []workflow.Workflow
 
-	var result []testkube.TestWorkflowWithExecutionSummary
-	if err := c.callTestKubeAPI(url, &result); err != nil {
-		return nil, fmt.Errorf("call testkube api: %w", err)
-	}
+	seen := make(map[workflow.ID]struct{})
+
+	for page := 0; ; page++ {
+		url := fmt.Sprintf(listWorkflowPath+pageQuery, c.url, orgID, envID, page, workflowPageSize)
 
-	ret := make([]workflow.Workflow, 0, len(result))
-	for _, w := range result {
-		if w.Workflow == nil {
-			// Skip this broken workflow.
-			continue
+		var result []testkube.TestWorkflowWithExecutionSummary
+		if err := c.callTestKubeAPI(ctx, http.MethodGet, url, nil, &result); err != nil {
+			return nil, fmt.Errorf("call testkube api: %w", err)
 		}
 
-		// Might not be any executions.
-		var lastExecutionAt time.Time
+		added := 0
 
-		var lastExecutionStatus string
+		for _, w := range result {
+			if w.Workflow == nil {
+				// Skip this broken workflow.
+				continue
+			}
 
-		if w.LatestExecution != nil {
-			lastExecutionAt = w.LatestExecution.ScheduledAt
-			if w.LatestExecution.Result != nil && w.LatestExecution.Result.Status != nil {
-				lastExecutionStatus = string(*w.LatestExecution.Result.Status)
+			if _, ok := seen[workflow.ID(w.Workflow.Name)]; ok {
+				continue
 			}
+
+			seen[workflow.ID(w.Workflow.Name)] = struct{}{}
+			added++
+
+			// Might not be any executions.
+			var lastExecutionAt time.Time
+
+			var lastExecutionStatus string
+
+			if w.LatestExecution != nil {
+				lastExecutionAt = w.LatestExecution.ScheduledAt
+				if w.LatestExecution.Result != nil && w.LatestExecution.Result.Status != nil {
+					lastExecutionStatus = string(*w.LatestExecution.Result.Status)
+				}
+			}
+
+			ret = append(ret, workflow.Workflow{
+				ID:                  workflow.ID(w.Workflow.Name),
+				Name:                w.Workflow.Name,
+				LastExecutionAt:     lastExecutionAt,
+				LastExecutionStatus: lastExecutionStatus,
+				Labels:              w.Workflow.Labels,
+			})
 		}
 
-		ret = append(ret, workflow.Workflow{
-			ID:                  workflow.ID(w.Workflow.Name),
-			Name:                w.Workflow.Name,
-			LastExecutionAt:     lastExecutionAt,
-			LastExecutionStatus: lastExecutionStatus,
-		})
+		// A short page is the last, and a page of nothing new means paging is not supported, so everything is here.
+		if len(result) < workflowPageSize || added == 0 {
+			return ret, nil
+		}
 	}
-
-	return ret, nil
 }
 
-// ListExecutions returns all executions under the passed organisation, environment, and workflow.
+// ListExecutions returns the passed page of executions under the passed organisation, environment, and workflow.
 // Sadly, all parameters are required due to the testkube API.
-func (c Client) ListExecutions(orgID organisation.ID, envID environment.ID, id workflow.ID) ([]workflow.Execution, error) {
-	url := fmt.Sprintf(listExecutionPath, c.url, orgID, envID, id)
+func (c Client) ListExecutions(ctx context.Context, orgID organisation.ID, envID environment.ID, id workflow.ID, page workflow.Page) ([]workflow.Execution, error) {
+	url := fmt.Sprintf(listExecutionPath+pageQuery, c.url, orgID, envID, id, page.Number, page.Size)
 
 	var result testkube.TestWorkflowExecutionsResult
-	if err := c.callTestKubeAPI(url, &result); err != nil {
+	if err := c.callTestKubeAPI(ctx, http.MethodGet, url, nil, &result); err != nil {
 		return nil, fmt.Errorf("call testkube api: %w", err)
 	}
 
 	ret := make([]workflow.Execution, 0, len(result.Results))
 	for _, e := range result.Results {
-		status := "unknown"
-		if e.Result != nil && e.Result.Status != nil {
-			status = string(*e.Result.Status)
+		ret = append(ret, convertExecution(e))
+	}
+
+	return ret, nil
+}
+
+// GetMetrics returns the metrics of the recent executions of the passed workflow.
+// Sadly, all parameters are required due to the testkube API.
+func (c Client) GetMetrics(ctx context.Context, orgID organisation.ID, envID environment.ID, id workflow.ID) (workflow.Metrics, error) {
+	url := fmt.Sprintf(metricsPath, c.url, orgID, envID, id)
+
+	var result testkube.ExecutionsMetrics
+	if err := c.callTestKubeAPI(ctx, http.MethodGet, url, nil, &result); err != nil {
+		return workflow.Metrics{}, fmt.Errorf("call testkube api: %w", err)
+	}
+
+	m := workflow.Metrics{
+		Executions: int(result.TotalExecutions),
+		Failed:     int(result.FailedExecutions),
+		// Without the executions themselves, the median is the best estimate of the average.
+		AverageDuration: time.Duration(result.ExecutionDurationP50ms) * time.Millisecond,
+	}
+
+	if len(result.Executions) > 0 {
+		var total time.Duration
+		for _, e := range result.Executions {
+			total += time.Duration(e.DurationMs) * time.Millisecond
+		}
+
+		m.AverageDuration = total / time.Duration(len(result.Executions))
+	}
+
+	return m, nil
+}
+
+// StartExecution starts a new execution of the passed workflow and returns it.
+// Sadly, all parameters are required due to the testkube API.
+func (c Client) StartExecution(ctx context.Context, orgID organisation.ID, envID environment.ID, id workflow.ID) (workflow.Execution, error) {
+	url := fmt.Sprintf(startExecutionPath, c.url, orgID, envID, id)
+
+	var result testkube.TestWorkflowExecution
+	if err := c.callTestKubeAPI(ctx, http.MethodPost, url, testkube.TestWorkflowExecutionRequest{}, &result); err != nil {
+		return workflow.Execution{}, fmt.Errorf("call testkube api: %w", err)
+	}
+
+	summary := testkube.TestWorkflowExecutionSummary{
+		Id:             result.Id,
+		RunnerId:       result.RunnerId,
+		Name:           result.Name,
+		Number:         result.Number,
+		ScheduledAt:    result.ScheduledAt,
+		Tags:           result.Tags,
+		RunningContext: result.RunningContext,
+		ConfigParams:   result.ConfigParams,
+	}
+
+	if result.Result != nil {
+		summary.Result = &testkube.TestWorkflowResultSummary{
+			Status:     result.Result.Status,
+			StartedAt:  result.Result.StartedAt,
+			FinishedAt: result.Result.FinishedAt,
+			DurationMs: result.Result.DurationMs,
+		}
+	}
+
+	return convertExecution(summary), nil
+}
+
+// AbortExecution aborts the passed execution.
+// Sadly, all parameters are required due to the testkube API.
+func (c Client) AbortExecution(ctx context.Context, orgID organisation.ID, envID environment.ID, id workflow.ExecutionID) error {
+	url := fmt.Sprintf(abortExecutionPath, c.url, orgID, envID, id)
+
+	if err := c.callTestKubeAPI(ctx, http.MethodPost, url, nil, nil); err != nil {
+		return fmt.Errorf("call testkube api: %w", err)
+	}
+
+	return nil
+}
+
+// ListSteps returns the steps of the passed execution, nested as described by the execution signature.
+// Sadly, all parameters are required due to the testkube API.
+func (c Client) ListSteps(ctx context.Context, orgID organisation.ID, envID environment.ID, id workflow.ExecutionID) ([]workflow.Step, error) {
+	result, err := c.getExecution(ctx, orgID, envID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var results map[string]testkube.TestWorkflowStepResult
+	if result.Result != nil {
+		results = result.Result.Steps
+	}
+
+	return convertSteps(result.Signature, results), nil
+}
+
+// StreamLogs sends the output of the passed execution to lines.
+// Running executions are followed via their notification stream until they finish,
+// whereas finished executions have their stored log read instead, where the hints marking
+// the start of each step are used to tell which step output each line.
+// Sadly, all parameters are required due to the testkube API.
+func (c Client) StreamLogs(ctx context.Context, orgID organisation.ID, envID environment.ID, id workflow.ExecutionID, lines chan<- workflow.LogLine) error {
+	result, err := c.getExecution(ctx, orgID, envID, id)
+	if err != nil {
+		return err
+	}
+
+	send := func(line workflow.LogLine) error {
+		select {
+		case lines <- line:
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("send log line: %w", ctx.Err())
 		}
+	}
+
+	if result.Result != nil && !result.Result.FinishedAt.IsZero() {
+		url := fmt.Sprintf(logsPath, c.url, orgID, envID, id)
+		known := stepRefs(result.Signature)
+
+		var step workflow.StepID
 
-		ret = append(ret, workflow.Execution{
-			ID:        workflow.ExecutionID(e.Id),
-			Name:      e.Name,
-			StartedAt: e.ScheduledAt,
-			Status:    status,
+		err := c.streamTestKubeAPI(ctx, url, func(line string) error {
+			if ref, name, ok := parseHint(line); ok {
+				// Only steps of the execution are followed, other hints say nothing about where the output is from.
+				if _, ok := known[ref]; ok && name == startHint {
+					step = workflow.StepID(ref)
+				}
+
+				// Instructions are for testkube, rather than people.
+				return nil
+			}
+
+			return send(workflow.LogLine{StepID: step, Text: line})
 		})
+		if err != nil {
+			return fmt.Errorf("stream testkube api: %w", err)
+		}
+
+		return nil
 	}
 
-	return ret, nil
+	url := fmt.Sprintf(notificationsPath, c.url, orgID, envID, id)
+
+	err = c.streamTestKubeAPI(ctx, url, func(line string) error {
+		// The notification stream uses server sent events, of which only the data is interesting.
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			return nil
+		}
+
+		var n testkube.TestWorkflowExecutionNotification
+		if err := json.Unmarshal([]byte(data), &n); err != nil {
+			return fmt.Errorf("decode notification: %w", err)
+		}
+
+		if n.Log == "" {
+			// Not every notification carries output, some are just result updates.
+			return nil
+		}
+
+		for text := range strings.Lines(n.Log) {
+			if err := send(workflow.LogLine{
+				StepID: workflow.StepID(n.Ref),
+				Text:   strings.TrimRight(text, "\r\n"),
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("stream testkube api: %w", err)
+	}
+
+	return nil
 }
 
-var errResponseCode = errors.New("unexpected HTTP status code")
+// The stored log of an execution is interspersed with instructions, which testkube uses to follow its progress.
+// Hints are instructions of the form: instruction prefix, hint prefix, step ref, separator, name,
+// and optionally a value separator, and a value. The start hint marks where the output of a step begins.
+const (
+	instructionPrefix      = "\u0001\u0005"
+	hintPrefix             = "\u0006"
+	instructionSeparator   = "\u0003"
+	instructionValuePrefix = "\u0004"
+	startHint              = "start"
+)
 
-func (c Client) callTestKubeAPI(url string, result any) error {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
-	defer cancel()
+// parseHint returns the step ref and name of the hint on the passed log line, if it is one.
+// The line may be prefixed, such as by a timestamp, but the hint runs to the end of the line.
+func parseHint(line string) (string, string, bool) {
+	_, instruction, ok := strings.Cut(line, instructionPrefix)
+	if !ok {
+		return "", "", false
+	}
+
+	hint, ok := strings.CutPrefix(instruction, hintPrefix)
+	if !ok {
+		return "", "", false
+	}
+
+	ref, name, ok := strings.Cut(hint, instructionSeparator)
+	if !ok {
+		return "", "", false
+	}
+
+	name, _, _ = strings.Cut(name, instructionValuePrefix)
+
+	return ref, name, true
+}
+
+// stepRefs returns the refs of every step described by the passed signature, however deeply nested.
+func stepRefs(signature []testkube.TestWorkflowSignature) map[string]struct{} {
+	refs := make(map[string]struct{})
+
+	for _, sig := range signature {
+		refs[sig.Ref] = struct{}{}
+
+		for ref := range stepRefs(sig.Children) {
+			refs[ref] = struct{}{}
+		}
+	}
+
+	return refs
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+func (c Client) getExecution(ctx context.Context, orgID organisation.ID, envID environment.ID, id workflow.ExecutionID) (testkube.TestWorkflowExecution, error) {
+	url := fmt.Sprintf(getExecutionPath, c.url, orgID, envID, id)
+
+	var result testkube.TestWorkflowExecution
+	if err := c.callTestKubeAPI(ctx, http.MethodGet, url, nil, &result); err != nil {
+		return testkube.TestWorkflowExecution{}, fmt.Errorf("call testkube api: %w", err)
+	}
+
+	return result, nil
+}
+
+func convertExecution(e testkube.TestWorkflowExecutionSummary) workflow.Execution {
+	execution := workflow.Execution{
+		ID:        workflow.ExecutionID(e.Id),
+		Name:      e.Name,
+		Number:    int(e.Number),
+		StartedAt: e.ScheduledAt,
+		Status:    "unknown",
+		RunnerID:  agent.ID(e.RunnerId),
+		Trigger:   convertTrigger(e.RunningContext),
+		Tags:      e.Tags,
+		Config:    convertConfig(e.ConfigParams),
+	}
+
+	if e.Result == nil {
+		return execution
+	}
+
+	if e.Result.Status != nil {
+		execution.Status = string(*e.Result.Status)
+	}
+
+	execution.FinishedAt = e.Result.FinishedAt
+
+	switch {
+	case e.Result.DurationMs > 0:
+		execution.Duration = time.Duration(e.Result.DurationMs) * time.Millisecond
+	case !e.Result.StartedAt.IsZero() && !e.Result.FinishedAt.IsZero():
+		execution.Duration = e.Result.FinishedAt.Sub(e.Result.StartedAt)
+	}
+
+	return execution
+}
+
+// convertTrigger describes what started an execution, in the terms a user would use.
+func convertTrigger(rc *testkube.TestWorkflowRunningContext) string {
+	if rc == nil {
+		return ""
+	}
+
+	if rc.Actor != nil && rc.Actor.Type_ != nil {
+		switch *rc.Actor.Type_ {
+		case testkube.CRON_TestWorkflowRunningContextActorType:
+			return "cron"
+		case testkube.TESTTRIGGER_TestWorkflowRunningContextActorType:
+			return "trigger"
+		case testkube.TESTWORKFLOW_TestWorkflowRunningContextActorType, testkube.TESTWORKFLOWEXECUTION_TestWorkflowRunningContextActorType:
+			return "workflow"
+		case testkube.USER_TestWorkflowRunningContextActorType, testkube.PROGRAM_TestWorkflowRunningContextActorType:
+			// Whoever it was, how they did it is more interesting.
+		}
+	}
+
+	if rc.Interface != nil && rc.Interface.Type_ != nil {
+		switch *rc.Interface.Type_ {
+		case testkube.CLI_TestWorkflowRunningContextInterfaceType, testkube.UI_TestWorkflowRunningContextInterfaceType:
+			return "manual"
+		case testkube.API_TestWorkflowRunningContextInterfaceType:
+			return "API"
+		case testkube.CI_TestWorkflowRunningContextInterfaceType:
+			return "CI/CD"
+		case testkube.INTERNAL_TestWorkflowRunningContextInterfaceType:
+			return "internal"
+		}
+	}
+
+	return ""
+}
+
+// convertConfig flattens the configuration of an execution into displayable values.
+func convertConfig(params map[string]testkube.TestWorkflowExecutionConfigValue) map[string]string {
+	if len(params) == 0 {
+		return nil
+	}
+
+	ret := make(map[string]string, len(params))
+
+	for name, p := range params {
+		switch {
+		case p.Sensitive:
+			ret[name] = "********"
+		case p.Value == "" && !p.EmptyValue:
+			ret[name] = p.DefaultValue
+		case p.Truncated:
+			ret[name] = p.Value + "…"
+		default:
+			ret[name] = p.Value
+		}
+	}
+
+	return ret
+}
+
+func convertSteps(signature []testkube.TestWorkflowSignature, results map[string]testkube.TestWorkflowStepResult) []workflow.Step {
+	ret := make([]workflow.Step, 0, len(signature))
+	for _, sig := range signature {
+		// Not every step is given a name, so fall back to something a user might recognise.
+		name := sig.Name
+		if name == "" {
+			name = sig.Category
+		}
+
+		if name == "" {
+			name = sig.Ref
+		}
+
+		step := workflow.Step{
+			ID:     workflow.StepID(sig.Ref),
+			Name:   name,
+			Status: "unknown",
+			Steps:  convertSteps(sig.Children, results),
+		}
+
+		// Steps that have not yet been queued will not have a result.
+		if r, ok := results[sig.Ref]; ok {
+			if r.Status != nil {
+				step.Status = string(*r.Status)
+			}
+
+			step.StartedAt = r.StartedAt
+
+			if !r.StartedAt.IsZero() && !r.FinishedAt.IsZero() {
+				step.Duration = r.FinishedAt.Sub(r.StartedAt)
+			}
+		}
+
+		ret = append(ret, step)
+	}
+
+	return ret
+}
+
+const maxLogLineSize = 1024 * 1024
+
+// authorise adds the current API token to the passed request.
+func (c Client) authorise(req *http.Request) error {
+	t, err := c.token.Token(req.Context())
 	if err != nil {
-		return fmt.Errorf("create request to %q: %w", url, err)
+		return fmt.Errorf("get token: %w", err)
 	}
 
-	req.Header.Add("Authorization", "Bearer "+c.token)
+	req.Header.Add("Authorization", "Bearer "+t)
 
-	res, err := http.DefaultClient.Do(req)
+	return nil
+}
+
+// callTestKubeAPI makes a request to the testkube API and decodes the response into result.
+// If body is nil, then no request body will be sent, otherwise it is encoded as JSON.
+// If result is nil, then the response body is ignored.
+func (c Client) callTestKubeAPI(ctx context.Context, method, url string, body, result any) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	var reqBody []byte
+
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encode request body: %w", err)
+		}
+
+		reqBody = b
+	}
+
+	res, err := c.do(ctx, method, url, reqBody)
 	if err != nil {
-		return fmt.Errorf("doing request to %q: %w", url, err)
+		return err
 	}
 
 	defer func() {
@@ -203,15 +651,9 @@ func (c Client) callTestKubeAPI(url string, result any) error {
 		_ = res.Body.Close()
 	}()
 
-	switch res.StatusCode {
-	case http.StatusRequestTimeout:
-		// There may be an issue at the agent, this is fine, but nothing will be returned.
+	if result == nil || res.StatusCode == http.StatusNoContent {
+		// Everything is fine, but there is nothing to decode.
 		return nil
-	case http.StatusOK:
-		// Everything is fine and working as expected!
-		break
-	default:
-		return fmt.Errorf("request to %q returned status %d: %w", url, res.StatusCode, errResponseCode)
 	}
 
 	if err := json.NewDecoder(res.Body).Decode(result); err != nil {
@@ -220,3 +662,146 @@ func (c Client) callTestKubeAPI(url string, result any) error {
 
 	return nil
 }
+
+// streamTestKubeAPI makes a GET request to the testkube API and calls fn for each line of the
+// response body as it arrives. This continues until the body is exhausted, fn returns an error,
+// or the passed context is cancelled.
+func (c Client) streamTestKubeAPI(ctx context.Context, url string, fn func(line string) error) error {
+	res, err := c.do(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		// Too late to handle this error, and we don't really care.
+		_ = res.Body.Close()
+	}()
+
+	scanner := bufio.NewScanner(res.Body)
+	// Log lines can be far longer than the default scanner buffer allows.
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLogLineSize)
+
+	for scanner.Scan() {
+		if err := fn(scanner.Text()); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read response body: %w", err)
+	}
+
+	return nil
+}
+
+// do makes a request to the testkube API, retrying according to the client's retry policy,
+// and returns the first successful response. Any response that is not a 2xx is an *APIError.
+// Requests to hosts, or agents, that keep failing are not made at all until they have had time to recover.
+func (c Client) do(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	key := circuitKey(url)
+	// Only requests that cannot have had an effect are safe to retry, a failed POST may have started an execution.
+	idempotent := method == http.MethodGet
+
+	for retry := 0; ; retry++ {
+		if err := c.breaker.allow(key, time.Now()); err != nil {
+			return nil, fmt.Errorf("request to %q: %w", url, err)
+		}
+
+		res, err := c.attempt(ctx, method, url, body)
+
+		delay := c.retry.backoff(retry)
+		retryable := idempotent
+
+		switch {
+		case err != nil && ctx.Err() != nil:
+			// Giving up says nothing about the health of the host.
+			c.breaker.abandon(key)
+
+			return nil, err
+		case err != nil:
+			c.breaker.record(key, false, time.Now())
+		case res.StatusCode >= http.StatusOK && res.StatusCode < http.StatusMultipleChoices:
+			c.breaker.record(key, true, time.Now())
+
+			return res, nil
+		case res.StatusCode == http.StatusRequestTimeout:
+			c.breaker.record(key, false, time.Now())
+
+			return nil, newAPIError(method, url, res)
+		case res.StatusCode == http.StatusTooManyRequests:
+			// The host is working, just busy, and nothing was done so any request can be retried.
+			c.breaker.record(key, true, time.Now())
+
+			retryable = true
+
+			if after, ok := retryAfter(res, time.Now()); ok {
+				delay = after
+				retryable = after <= c.retry.MaxDelay
+			}
+
+			err = newAPIError(method, url, res)
+		case res.StatusCode >= http.StatusInternalServerError:
+			c.breaker.record(key, false, time.Now())
+
+			err = newAPIError(method, url, res)
+		default:
+			// The host is working, but the request is wrong, so retrying will not help.
+			c.breaker.record(key, true, time.Now())
+
+			if res.StatusCode == http.StatusUnauthorized {
+				c.invalidateToken(res)
+			}
+
+			return nil, newAPIError(method, url, res)
+		}
+
+		if !retryable || retry+1 >= c.retry.MaxAttempts {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("wait to retry %q: %w", url, ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
+
+// invalidateToken tells the token source that the token the passed response rejected is no good,
+// so that a remembered token is looked up again for the next request.
+func (c Client) invalidateToken(res *http.Response) {
+	inv, ok := c.token.(token.Invalidator)
+	if !ok || res.Request == nil {
+		return
+	}
+
+	inv.Invalidate(strings.TrimPrefix(res.Request.Header.Get("Authorization"), "Bearer "))
+}
+
+// attempt makes a single request to the testkube API.
+func (c Client) attempt(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	var reqBody io.Reader = http.NoBody
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("create request to %q: %w", url, err)
+	}
+
+	if err := c.authorise(req); err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("doing request to %q: %w", url, err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,454 @@
+package testkube_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"tkview/internal/environment"
+	"tkview/internal/fake"
+	"tkview/internal/organisation"
+	"tkview/internal/testkube"
+	"tkview/internal/token"
+	"tkview/internal/workflow"
+)
+
+const (
+	demoOrg   organisation.ID = "tkcorg_acme"
+	demoProd  environment.ID  = "tkcenv_prod"
+	demoStage environment.ID  = "tkcenv_stage"
+)
+
+// noRetries keeps failing tests quick, retries are tested separately.
+var noRetries = testkube.RetryPolicy{MaxAttempts: 1}
+
+// newDemoClient returns a client of a fake testkube API serving the demo fixtures.
+func newDemoClient(t *testing.T) testkube.Client {
+	t.Helper()
+
+	return newClient(t, nil, token.Static("token"))
+}
+
+// newClient returns a client of a fake testkube API serving the demo fixtures, through wrap if it is not nil.
+func newClient(t *testing.T, wrap func(http.Handler) http.Handler, src token.Source) testkube.Client {
+	t.Helper()
+
+	srv, err := fake.New(fake.Demo())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var h http.Handler = srv
+	if wrap != nil {
+		h = wrap(h)
+	}
+
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	return testkube.New(ts.URL, src).WithRetryPolicy(noRetries)
+}
+
+func TestListOrganisationsEnvironmentsAndAgents(t *testing.T) {
+	ctx := context.Background()
+	c := newDemoClient(t)
+
+	orgs, err := c.ListOrganisations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []organisation.Organisation{{ID: "tkcorg_acme", Name: "Acme"}, {ID: "tkcorg_globex", Name: "Globex"}}
+	if !slices.Equal(orgs, want) {
+		t.Errorf("ListOrganisations() = %v, want %v", orgs, want)
+	}
+
+	envs, err := c.ListEnvironments(ctx, demoOrg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantEnvs := []environment.Environment{{ID: demoProd, Name: "production"}, {ID: demoStage, Name: "staging"}}
+	if !slices.Equal(envs, wantEnvs) {
+		t.Errorf("ListEnvironments() = %v, want %v", envs, wantEnvs)
+	}
+
+	agents, err := c.ListAgents(ctx, demoOrg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(agents) != 2 {
+		t.Fatalf("ListAgents() returned %d agents, want 2", len(agents))
+	}
+
+	for _, a := range agents {
+		if a.ID == "" || a.Name == "" || a.Type == "" || a.Version == "" || a.LastSeen.IsZero() {
+			t.Errorf("ListAgents() returned incomplete agent %+v", a)
+		}
+	}
+}
+
+func TestListWorkflows(t *testing.T) {
+	workflows, err := newDemoClient(t).ListWorkflows(context.Background(), demoOrg, demoProd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, w := range workflows {
+		names = append(names, w.Name)
+
+		if w.ID != workflow.ID(w.Name) {
+			t.Errorf("workflow %q has ID %q, want its name", w.Name, w.ID)
+		}
+	}
+
+	slices.Sort(names)
+
+	want := []string{"api-tests", "e2e-cypress", "nightly-cleanup", "smoke"}
+	if !slices.Equal(names, want) {
+		t.Errorf("ListWorkflows() = %v, want %v", names, want)
+	}
+}
+
+func TestListExecutionsPaging(t *testing.T) {
+	ctx := context.Background()
+	c := newDemoClient(t)
+
+	// The smoke workflow has 8 executions.
+	const size = 3
+
+	tests := []struct {
+		page int
+		want int
+	}{
+		{page: 0, want: size},
+		{page: 1, want: size},
+		{page: 2, want: 2},
+		{page: 3, want: 0},
+	}
+
+	seen := make(map[workflow.ExecutionID]bool)
+
+	var last time.Time
+
+	for _, tt := range tests {
+		executions, err := c.ListExecutions(ctx, demoOrg, demoProd, "smoke", workflow.Page{Number: tt.page, Size: size})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(executions) != tt.want {
+			t.Errorf("page %d has %d executions, want %d", tt.page, len(executions), tt.want)
+		}
+
+		for _, e := range executions {
+			if seen[e.ID] {
+				t.Errorf("execution %q is on more than one page", e.ID)
+			}
+
+			seen[e.ID] = true
+
+			if !last.IsZero() && e.StartedAt.After(last) {
+				t.Errorf("execution %q started after the one before it, want most recent first", e.ID)
+			}
+
+			last = e.StartedAt
+
+			if e.Status != "passed" && e.Status != "failed" {
+				t.Errorf("execution %q has status %q", e.ID, e.Status)
+			}
+		}
+	}
+}
+
+func TestListSteps(t *testing.T) {
+	ctx := context.Background()
+	c := newDemoClient(t)
+
+	executions, err := c.ListExecutions(ctx, demoOrg, demoProd, "api-tests", workflow.Page{Number: 0, Size: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps, err := c.ListSteps(ctx, demoOrg, demoProd, executions[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []workflow.StepID
+	for _, s := range steps {
+		ids = append(ids, s.ID)
+	}
+
+	want := []workflow.StepID{"r1setup", "r2tests", "r3report"}
+	if !slices.Equal(ids, want) {
+		t.Errorf("ListSteps() = %v, want %v", ids, want)
+	}
+}
+
+func TestStartAndAbortExecution(t *testing.T) {
+	ctx := context.Background()
+	c := newDemoClient(t)
+
+	e, err := c.StartExecution(ctx, demoOrg, demoProd, "smoke")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e.Status != "running" || e.Trigger != "manual" {
+		t.Errorf("StartExecution() = %+v, want a manually triggered running execution", e)
+	}
+
+	latest, err := c.ListExecutions(ctx, demoOrg, demoProd, "smoke", workflow.Page{Number: 0, Size: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(latest) != 1 || latest[0].ID != e.ID {
+		t.Fatalf("most recent execution = %v, want the started %q", latest, e.ID)
+	}
+
+	if err := c.AbortExecution(ctx, demoOrg, demoProd, e.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	latest, err = c.ListExecutions(ctx, demoOrg, demoProd, "smoke", workflow.Page{Number: 0, Size: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if latest[0].Status != "aborted" {
+		t.Errorf("aborted execution has status %q", latest[0].Status)
+	}
+
+	// It is no longer running, so cannot be aborted again.
+	var apiErr *testkube.APIError
+	if err := c.AbortExecution(ctx, demoOrg, demoProd, e.ID); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("aborting again: error = %v, want a %d APIError", err, http.StatusBadRequest)
+	}
+}
+
+// streamLogs returns every line of the passed execution's logs.
+func streamLogs(t *testing.T, c testkube.Client, envID environment.ID, id workflow.ExecutionID) []workflow.LogLine {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var (
+		got  []workflow.LogLine
+		done = make(chan struct{})
+		ch   = make(chan workflow.LogLine)
+	)
+
+	go func() {
+		defer close(done)
+
+		for l := range ch {
+			got = append(got, l)
+		}
+	}()
+
+	err := c.StreamLogs(ctx, demoOrg, envID, id, ch)
+
+	close(ch)
+	<-done
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return got
+}
+
+func TestStreamLogsOfFinishedExecution(t *testing.T) {
+	ctx := context.Background()
+	c := newDemoClient(t)
+
+	executions, err := c.ListExecutions(ctx, demoOrg, demoProd, "api-tests", workflow.Page{Number: 0, Size: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := streamLogs(t, c, demoProd, executions[0].ID)
+	if len(lines) == 0 {
+		t.Fatal("StreamLogs() sent nothing")
+	}
+
+	// The start hints say which step each line is from, and are not sent themselves.
+	var steps []workflow.StepID
+
+	for _, l := range lines {
+		if strings.ContainsAny(l.Text, "\u0001\u0005") {
+			t.Errorf("StreamLogs() sent instruction %q", l.Text)
+		}
+
+		if len(steps) == 0 || steps[len(steps)-1] != l.StepID {
+			steps = append(steps, l.StepID)
+		}
+	}
+
+	// The tests are run by steps nested within r2tests.
+	want := []workflow.StepID{"r1setup", "r2a", "r2b", "r3report"}
+	if !slices.Equal(steps, want) {
+		t.Errorf("StreamLogs() lines are from steps %v, want %v", steps, want)
+	}
+
+	if lines[0].Text != "Preparing test data" {
+		t.Errorf("first line = %q, want %q", lines[0].Text, "Preparing test data")
+	}
+}
+
+func TestStreamLogsOfRunningExecution(t *testing.T) {
+	ctx := context.Background()
+	c := newDemoClient(t)
+
+	executions, err := c.ListExecutions(ctx, demoOrg, demoStage, "load-k6", workflow.Page{Number: 0, Size: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if executions[0].Status != "running" {
+		t.Fatalf("latest load-k6 execution has status %q, want running", executions[0].Status)
+	}
+
+	lines := streamLogs(t, c, demoStage, executions[0].ID)
+	if len(lines) == 0 {
+		t.Fatal("StreamLogs() sent nothing")
+	}
+
+	for _, l := range lines {
+		if l.StepID == "" || l.Text == "" || strings.HasSuffix(l.Text, "\n") {
+			t.Errorf("StreamLogs() sent %+v, want a step and a single line", l)
+		}
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	ctx := context.Background()
+	c := newDemoClient(t)
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{
+			name: "environments of unknown organisation",
+			call: func() error {
+				_, err := c.ListEnvironments(ctx, "tkcorg_missing")
+				return err
+			},
+		},
+		{
+			name: "executions of unknown workflow",
+			call: func() error {
+				_, err := c.ListExecutions(ctx, demoOrg, demoProd, "missing", workflow.Page{Number: 0, Size: 1})
+				return err
+			},
+		},
+		{
+			name: "steps of unknown execution",
+			call: func() error {
+				_, err := c.ListSteps(ctx, demoOrg, demoProd, "missing")
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+
+			var apiErr *testkube.APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Method != http.MethodGet {
+				t.Fatalf("error = %v, want a GET %d APIError", err, http.StatusNotFound)
+			}
+
+			if !testkube.IsNotFound(err) || testkube.IsUnauthorized(err) {
+				t.Errorf("IsNotFound() = %t, IsUnauthorized() = %t, want only not found", testkube.IsNotFound(err), testkube.IsUnauthorized(err))
+			}
+		})
+	}
+}
+
+// rotatingSource provides "expired" until rotated, and "valid" afterwards, counting how many times it is asked.
+type rotatingSource struct {
+	mu      sync.Mutex
+	rotated bool
+	asked   int
+}
+
+func (s *rotatingSource) Token(context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.asked++
+
+	if s.rotated {
+		return "valid", nil
+	}
+
+	return "expired", nil
+}
+
+func TestUnauthorized(t *testing.T) {
+	ctx := context.Background()
+	src := &rotatingSource{}
+
+	// Only the valid token is accepted, as with an expired token.
+	rejectExpired := func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer valid" {
+				w.Header().Set("Content-Type", "application/problem+json")
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"title": "Unauthorized", "detail": "token has expired"}`))
+
+				return
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}
+
+	c := newClient(t, rejectExpired, token.Cache(src))
+
+	_, err := c.ListOrganisations(ctx)
+
+	var apiErr *testkube.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("ListOrganisations() error = %v, want a %d APIError", err, http.StatusUnauthorized)
+	}
+
+	if !testkube.IsUnauthorized(err) || apiErr.Problem == nil || apiErr.Problem.Detail != "token has expired" {
+		t.Errorf("ListOrganisations() error = %v, want unauthorized with the problem detail", err)
+	}
+
+	// Once the token is rotated, the rejected token is not reused from the cache.
+	src.mu.Lock()
+	src.rotated = true
+	src.mu.Unlock()
+
+	if _, err := c.ListOrganisations(ctx); err != nil {
+		t.Fatalf("ListOrganisations() after rotating the token: %v", err)
+	}
+
+	if _, err := c.ListOrganisations(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The valid token is cached, having only been asked for once since the rejection.
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	if src.asked != 2 {
+		t.Errorf("token source was asked %d times, want 2", src.asked)
+	}
+}
@@ -0,0 +1,122 @@
+package testkube
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrAgentTimedOut is returned when the control plane gave up waiting for an environment's agent to respond.
+// The agent may be offline or overloaded, either way, nothing was returned.
+var ErrAgentTimedOut = errors.New("agent timed out")
+
+// maxProblemSize limits how much of an error response is read, looking for a problem.
+const maxProblemSize = 64 * 1024
+
+// APIError is returned when the testkube API responds with anything other than success.
+// Use errors.As to find it, or the Is functions to ask what kind of failure it was.
+type APIError struct {
+	StatusCode int
+	Method     string
+	// Endpoint is the url that the request was made to.
+	Endpoint string
+	// RequestID identifies the request to testkube, for looking it up in their logs, it may be empty.
+	RequestID string
+	// Problem is the explanation testkube gave for the error, it is nil if there was none.
+	Problem *Problem
+}
+
+// Problem is the RFC 7807 problem details that testkube returns to explain an error.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail"`
+	Instance string `json:"instance"`
+}
+
+// newAPIError creates the error for the passed unsuccessful response, consuming its body.
+func newAPIError(method, url string, res *http.Response) *APIError {
+	defer func() {
+		// Too late to handle this error, and we don't really care.
+		_ = res.Body.Close()
+	}()
+
+	e := &APIError{
+		StatusCode: res.StatusCode,
+		Method:     method,
+		Endpoint:   url,
+		RequestID:  res.Header.Get("X-Request-Id"),
+	}
+
+	// Not every error has a problem body, in which case there is nothing more to say.
+	var p Problem
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxProblemSize)).Decode(&p); err == nil && (p.Title != "" || p.Detail != "") {
+		e.Problem = &p
+	}
+
+	return e
+}
+
+// Error describes the failed request, along with any explanation testkube gave.
+func (e *APIError) Error() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s %q returned status %d", e.Method, e.Endpoint, e.StatusCode)
+
+	if e.Problem != nil {
+		for _, s := range []string{e.Problem.Title, e.Problem.Detail} {
+			if s != "" {
+				b.WriteString(": " + s)
+			}
+		}
+	}
+
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, " (request ID %s)", e.RequestID)
+	}
+
+	return b.String()
+}
+
+// Is reports a request timeout as ErrAgentTimedOut, as that is what the control plane means by it.
+func (e *APIError) Is(target error) bool {
+	return target == ErrAgentTimedOut && e.StatusCode == http.StatusRequestTimeout
+}
+
+// IsUnauthorized reports whether err was caused by the API token being rejected, such as when it has expired.
+func IsUnauthorized(err error) bool {
+	var e *APIError
+
+	return errors.As(err, &e) && (e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden)
+}
+
+// IsNotFound reports whether err was caused by something that does not exist, such as a deleted workflow.
+func IsNotFound(err error) bool {
+	var e *APIError
+
+	return errors.As(err, &e) && e.StatusCode == http.StatusNotFound
+}
+
+// IsAgentUnavailable reports whether err was caused by an environment's agent, or the API itself,
+// not responding, or by having given up on it after repeated failures.
+func IsAgentUnavailable(err error) bool {
+	if errors.Is(err, ErrAgentTimedOut) || errors.Is(err, errCircuitOpen) {
+		return true
+	}
+
+	var e *APIError
+	if !errors.As(err, &e) {
+		return false
+	}
+
+	switch e.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
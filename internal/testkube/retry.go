@@ -0,0 +1,175 @@
+package testkube
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RetryPolicy controls how failed requests to the testkube API are retried.
+// Only requests that failed without effect are retried: network errors, 5xx responses to GET requests,
+// and 429 responses to any request. Between attempts, there is an exponential backoff with full jitter.
+type RetryPolicy struct {
+	// MaxAttempts is the most times a request is made, values below 1 mean the request is only made once.
+	MaxAttempts int
+	// BaseDelay is the longest delay before the second attempt, each later attempt doubles it.
+	BaseDelay time.Duration
+	// MaxDelay is the longest delay between any two attempts.
+	// A 429 response asking for a longer delay than this is not retried.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is the retry policy used by clients created with New().
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   250 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+}
+
+// WithRetryPolicy returns a copy of the client that retries requests according to the passed policy.
+func (c Client) WithRetryPolicy(p RetryPolicy) Client {
+	c.retry = p
+
+	return c
+}
+
+// maxBackoffShift stops the exponential backoff from overflowing, long before which MaxDelay will apply.
+const maxBackoffShift = 30
+
+// backoff returns how long to wait before the passed retry, where the first retry is 0.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	ceiling := p.BaseDelay << min(retry, maxBackoffShift)
+	if ceiling <= 0 || ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+
+	if ceiling <= 0 {
+		return 0
+	}
+
+	// Full jitter spreads out the retries of the many requests made during a refresh.
+	return rand.N(ceiling) //nolint:gosec // Jitter does not need to be cryptographically random.
+}
+
+// retryAfter parses the Retry-After header of a response, which is either a number of seconds or a date.
+func retryAfter(res *http.Response, now time.Time) (time.Duration, bool) {
+	v := res.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(v); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+
+	return 0, false
+}
+
+const (
+	// breakerThreshold is the number of consecutive failures that opens a circuit.
+	breakerThreshold = 5
+	// breakerCooldown is how long a circuit stays open before a single request is let through to test it.
+	breakerCooldown = 30 * time.Second
+)
+
+var errCircuitOpen = errors.New("circuit open after repeated failures")
+
+// breaker is a circuit breaker for each of the hosts, and agents, that the client talks to.
+// Once a circuit is open, requests fail immediately, so that automatic refreshes do not keep
+// hammering something that is down. After a cooldown, one request is let through to test whether it has recovered.
+type breaker struct {
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	failures  int
+	openUntil time.Time
+	// probing is set whilst the single request testing an open circuit is in flight.
+	probing bool
+}
+
+func newBreaker() *breaker {
+	return &breaker{circuits: make(map[string]*circuit)}
+}
+
+// allow returns an error if requests to the passed circuit should not be made.
+func (b *breaker) allow(key string, now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[key]
+	if !ok || c.failures < breakerThreshold {
+		return nil
+	}
+
+	if now.Before(c.openUntil) || c.probing {
+		return fmt.Errorf("%s until %s: %w", key, c.openUntil.Format(time.TimeOnly), errCircuitOpen)
+	}
+
+	c.probing = true
+
+	return nil
+}
+
+// record notes whether a request to the passed circuit reached a working server.
+func (b *breaker) record(key string, ok bool, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ok {
+		delete(b.circuits, key)
+		return
+	}
+
+	c, found := b.circuits[key]
+	if !found {
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+
+	c.failures++
+	c.probing = false
+
+	if c.failures >= breakerThreshold {
+		c.openUntil = now.Add(breakerCooldown)
+	}
+}
+
+// abandon notes that a request allowed to the passed circuit was given up on, so told nothing about its health.
+func (b *breaker) abandon(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if c, ok := b.circuits[key]; ok {
+		c.probing = false
+	}
+}
+
+// circuitKey returns the circuit that a request url belongs to.
+// Agents are reached through the control plane, so share its host, but each one fails independently,
+// so requests to an environment's agent get a circuit of their own.
+func circuitKey(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	key := u.Host
+
+	if before, _, ok := strings.Cut(u.Path, "/agent/"); ok {
+		key += before
+	}
+
+	return key
+}
@@ -0,0 +1,309 @@
+package testkube
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"tkview/internal/token"
+)
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		retry   int
+		ceiling time.Duration
+	}{
+		{name: "first retry", policy: p, retry: 0, ceiling: 100 * time.Millisecond},
+		{name: "doubles", policy: p, retry: 1, ceiling: 200 * time.Millisecond},
+		{name: "doubles again", policy: p, retry: 2, ceiling: 400 * time.Millisecond},
+		{name: "capped", policy: p, retry: 4, ceiling: time.Second},
+		{name: "does not overflow", policy: p, retry: 100, ceiling: time.Second},
+		{name: "no delay", policy: RetryPolicy{MaxAttempts: 3}, retry: 1, ceiling: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The jitter is random, so check it stays within bounds, and is spread out.
+			const samples = 200
+
+			seen := make(map[time.Duration]struct{})
+
+			for range samples {
+				d := tt.policy.backoff(tt.retry)
+				if d < 0 || (d >= tt.ceiling && tt.ceiling > 0) || (tt.ceiling == 0 && d != 0) {
+					t.Fatalf("backoff(%d) = %s, want within [0, %s)", tt.retry, d, tt.ceiling)
+				}
+
+				seen[d] = struct{}{}
+			}
+
+			if tt.ceiling > 0 && len(seen) < 2 {
+				t.Errorf("backoff(%d) always returned the same delay, want jitter", tt.retry)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		header string
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "seconds", header: "3", want: 3 * time.Second, wantOK: true},
+		{name: "zero seconds", header: "0", want: 0, wantOK: true},
+		{name: "date", header: now.Add(10 * time.Second).Format(http.TimeFormat), want: 10 * time.Second, wantOK: true},
+		{name: "date in the past", header: now.Add(-time.Minute).Format(http.TimeFormat), want: 0, wantOK: true},
+		{name: "missing", header: "", wantOK: false},
+		{name: "malformed", header: "soon", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &http.Response{Header: http.Header{}}
+			if tt.header != "" {
+				res.Header.Set("Retry-After", tt.header)
+			}
+
+			got, ok := retryAfter(res, now)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("retryAfter(%q) = %s, %t, want %s, %t", tt.header, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	start := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+	cooldown := start.Add(breakerCooldown)
+
+	type step struct {
+		do func(b *breaker, now time.Time)
+		// at is when the step happens, and whether requests are then allowed.
+		at      time.Time
+		allowed bool
+	}
+
+	fail := func(b *breaker, now time.Time) { b.record("host", false, now) }
+	succeed := func(b *breaker, now time.Time) { b.record("host", true, now) }
+	abandon := func(b *breaker, _ time.Time) { b.abandon("host") }
+	failTimes := func(n int) []step {
+		steps := make([]step, 0, n)
+		for i := range n {
+			steps = append(steps, step{do: fail, at: start, allowed: i+1 < breakerThreshold})
+		}
+
+		return steps
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name:  "stays closed below the threshold",
+			steps: failTimes(breakerThreshold - 1),
+		},
+		{
+			name:  "opens at the threshold",
+			steps: failTimes(breakerThreshold),
+		},
+		{
+			name: "success resets the count",
+			steps: append(failTimes(breakerThreshold-1),
+				step{do: succeed, at: start, allowed: true},
+				step{do: fail, at: start, allowed: true},
+			),
+		},
+		{
+			name: "lets one probe through after the cooldown, which closes it on success",
+			steps: append(failTimes(breakerThreshold),
+				step{at: cooldown.Add(-time.Second), allowed: false},
+				// The probe is allowed, but whilst it is in flight, nothing else is.
+				step{at: cooldown, allowed: true},
+				step{at: cooldown, allowed: false},
+				step{do: succeed, at: cooldown, allowed: true},
+			),
+		},
+		{
+			name: "a failed probe opens it for another cooldown",
+			steps: append(failTimes(breakerThreshold),
+				step{at: cooldown, allowed: true},
+				step{do: fail, at: cooldown, allowed: false},
+				step{at: cooldown.Add(breakerCooldown - time.Second), allowed: false},
+				step{at: cooldown.Add(breakerCooldown), allowed: true},
+			),
+		},
+		{
+			name: "an abandoned probe lets another through",
+			steps: append(failTimes(breakerThreshold),
+				step{at: cooldown, allowed: true},
+				step{do: abandon, at: cooldown, allowed: true},
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBreaker()
+
+			for i, s := range tt.steps {
+				if s.do != nil {
+					s.do(b, s.at)
+				}
+
+				err := b.allow("host", s.at)
+				if s.allowed != (err == nil) {
+					t.Fatalf("step %d: allow() error = %v, want allowed %t", i, err, s.allowed)
+				}
+
+				if err != nil && !errors.Is(err, errCircuitOpen) {
+					t.Fatalf("step %d: allow() error = %v, want %v", i, err, errCircuitOpen)
+				}
+
+				// Other circuits are unaffected.
+				if err := b.allow("other", s.at); err != nil {
+					t.Fatalf("step %d: allow() of another circuit error = %v", i, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCircuitKey(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://api.testkube.io/organizations", want: "api.testkube.io"},
+		{url: "https://api.testkube.io/organizations/o/environments/e/agent/test-workflows", want: "api.testkube.io/organizations/o/environments/e"},
+		{url: "https://api.testkube.io/organizations/o/environments/f/agent/test-workflows", want: "api.testkube.io/organizations/o/environments/f"},
+	}
+
+	for _, tt := range tests {
+		if got := circuitKey(tt.url); got != tt.want {
+			t.Errorf("circuitKey(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+// newStatusServer returns a client of a server that responds to everything with the passed status,
+// and the number of requests the server has received.
+func newStatusServer(t *testing.T, status int, header http.Header, p RetryPolicy) (Client, *atomic.Int32) {
+	t.Helper()
+
+	var requests atomic.Int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(ts.Close)
+
+	return New(ts.URL, token.Static("token")).WithRetryPolicy(p), &requests
+}
+
+func TestDoRetries(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	retryAfterSeconds := func(d time.Duration) http.Header {
+		return http.Header{"Retry-After": {strconv.Itoa(int(d.Seconds()))}}
+	}
+
+	tests := []struct {
+		name         string
+		method       string
+		status       int
+		header       http.Header
+		wantAttempts int
+		wantErr      error
+	}{
+		{name: "success", method: http.MethodGet, status: http.StatusOK, wantAttempts: 1},
+		{name: "GET server error is retried", method: http.MethodGet, status: http.StatusBadGateway, wantAttempts: p.MaxAttempts},
+		{name: "POST server error is not retried", method: http.MethodPost, status: http.StatusBadGateway, wantAttempts: 1},
+		{name: "client error is not retried", method: http.MethodGet, status: http.StatusNotFound, wantAttempts: 1},
+		{
+			name: "too many requests is retried, even for a POST", method: http.MethodPost, status: http.StatusTooManyRequests,
+			header: retryAfterSeconds(0), wantAttempts: p.MaxAttempts,
+		},
+		{
+			name: "too many requests is not retried after longer than the maximum delay", method: http.MethodGet,
+			status: http.StatusTooManyRequests, header: retryAfterSeconds(time.Minute), wantAttempts: 1,
+		},
+		{
+			name: "request timeout is an agent timeout", method: http.MethodGet, status: http.StatusRequestTimeout,
+			wantAttempts: 1, wantErr: ErrAgentTimedOut,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, requests := newStatusServer(t, tt.status, tt.header, p)
+
+			res, err := c.do(context.Background(), tt.method, c.url+"/organizations", nil)
+			if res != nil {
+				_ = res.Body.Close()
+			}
+
+			if got := int(requests.Load()); got != tt.wantAttempts {
+				t.Errorf("made %d attempts, want %d", got, tt.wantAttempts)
+			}
+
+			if tt.status < http.StatusMultipleChoices {
+				if err != nil {
+					t.Errorf("do() error = %v, want none", err)
+				}
+
+				return
+			}
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+				t.Errorf("do() error = %v, want an *APIError with status %d", err, tt.status)
+			}
+
+			if res != nil {
+				t.Errorf("do() returned a response with error %v, want none", err)
+			}
+
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("do() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDoFailsFastOnceCircuitOpens(t *testing.T) {
+	c, requests := newStatusServer(t, http.StatusServiceUnavailable, nil, RetryPolicy{MaxAttempts: 1})
+	url := c.url + "/organizations"
+
+	for range breakerThreshold {
+		if _, err := c.do(context.Background(), http.MethodGet, url, nil); err == nil {
+			t.Fatal("do() error = nil, want the server's error")
+		}
+	}
+
+	if _, err := c.do(context.Background(), http.MethodGet, url, nil); !errors.Is(err, errCircuitOpen) {
+		t.Errorf("do() once the circuit opened error = %v, want %v", err, errCircuitOpen)
+	}
+
+	if got := int(requests.Load()); got != breakerThreshold {
+		t.Errorf("server received %d requests, want %d, none once the circuit opened", got, breakerThreshold)
+	}
+}
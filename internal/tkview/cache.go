@@ -0,0 +1,460 @@
+package tkview
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"tkview/internal/agent"
+	"tkview/internal/environment"
+	"tkview/internal/organisation"
+	"tkview/internal/workflow"
+)
+
+// CacheTTLs are how long each kind of resource is fresh for once loaded.
+// Resources that change often, such as executions, should have shorter TTLs than those that rarely do.
+type CacheTTLs struct {
+	Organisations time.Duration
+	Environments  time.Duration
+	Agents        time.Duration
+	Workflows     time.Duration
+	Executions    time.Duration
+	Steps         time.Duration
+	Metrics       time.Duration
+}
+
+// DefaultCacheTTLs keep executions, and their steps, fresh enough to follow them whilst they run.
+var DefaultCacheTTLs = CacheTTLs{
+	Organisations: 5 * time.Minute,
+	Environments:  5 * time.Minute,
+	Agents:        30 * time.Second,
+	Workflows:     10 * time.Second,
+	Executions:    5 * time.Second,
+	Steps:         5 * time.Second,
+	Metrics:       time.Minute,
+}
+
+// cacheMaxAge is how long a resource is kept for, stale or not, before it is dropped rather than shown.
+// Anything older is unlikely to still be of use, and would otherwise be kept in the cache file forever.
+const cacheMaxAge = 7 * 24 * time.Hour
+
+// revalidateTimeout limits how long a background revalidation may take,
+// as nothing is waiting on it to be cancelled.
+const revalidateTimeout = 30 * time.Second
+
+// Cache is a client that remembers what it has loaded from another client.
+// Fresh resources are returned without asking the client again. Stale resources are still returned
+// straight away, whilst they are loaded again in the background, see Revalidated.
+// Starting and aborting executions, and streaming logs, always go to the client.
+//
+// A Cache is safe for concurrent use.
+type Cache struct {
+	client client
+	ttls   CacheTTLs
+	path   string
+	now    func() time.Time
+
+	mu           sync.Mutex
+	entries      map[string]cacheEntry
+	revalidating map[string]struct{}
+	// forgotten are the keys being revalidated that have been forgotten since, so what is revalidated is out of date.
+	forgotten   map[string]struct{}
+	revalidated chan struct{}
+}
+
+// cacheEntry is a loaded resource. Entries read from the cache file are kept encoded until first used,
+// as only then is the type to decode them into known.
+type cacheEntry struct {
+	value   any
+	encoded json.RawMessage
+	loaded  time.Time
+	// expired is set by Expire, which keeps when the resource was loaded, so that it is not dropped as too old.
+	expired bool
+}
+
+// cacheFileEntry is how a cacheEntry is kept in the cache file.
+type cacheFileEntry struct {
+	Loaded time.Time       `json:"loaded"`
+	Value  json.RawMessage `json:"value"`
+}
+
+// NewCache creates a Cache in front of the passed client, using the passed TTLs.
+// If path is not empty, the Cache starts with whatever was last saved there, see Save,
+// apart from anything loaded more than cacheMaxAge ago.
+// A cache file that cannot be read is ignored, as everything in it can be loaded again.
+func NewCache(c client, ttls CacheTTLs, path string) *Cache {
+	cache := &Cache{
+		client:       c,
+		ttls:         ttls,
+		path:         path,
+		now:          time.Now,
+		entries:      make(map[string]cacheEntry),
+		revalidating: make(map[string]struct{}),
+		forgotten:    make(map[string]struct{}),
+		revalidated:  make(chan struct{}, 1),
+	}
+
+	if path == "" {
+		return cache
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+
+	var saved map[string]cacheFileEntry
+	if err := json.Unmarshal(b, &saved); err != nil {
+		return cache
+	}
+
+	for key, e := range saved {
+		if cache.tooOld(e.Loaded) {
+			continue
+		}
+
+		cache.entries[key] = cacheEntry{encoded: e.Value, loaded: e.Loaded}
+	}
+
+	return cache
+}
+
+// DefaultCacheDir returns the directory that cache files are kept in, within the user's cache directory.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("find user cache dir: %w", err)
+	}
+
+	return filepath.Join(dir, "tkview"), nil
+}
+
+// Save writes everything in the Cache to its cache file, so that the next Cache created with it
+// has something to show straight away. Anything loaded more than cacheMaxAge ago is left out.
+// It does nothing if the Cache has no cache file.
+func (c *Cache) Save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	c.mu.Lock()
+
+	saved := make(map[string]cacheFileEntry, len(c.entries))
+
+	for key, e := range c.entries {
+		if c.tooOld(e.loaded) {
+			continue
+		}
+
+		value := e.encoded
+		if value == nil {
+			b, err := json.Marshal(e.value)
+			if err != nil {
+				c.mu.Unlock()
+
+				return fmt.Errorf("encode cache entry %q: %w", key, err)
+			}
+
+			value = b
+		}
+
+		saved[key] = cacheFileEntry{Loaded: e.loaded, Value: value}
+	}
+
+	c.mu.Unlock()
+
+	b, err := json.Marshal(saved)
+	if err != nil {
+		return fmt.Errorf("encode cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return fmt.Errorf("create cache dir: %w", err)
+	}
+
+	// Write to a temporary file first, so that a crash part way through leaves the last cache file intact.
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return fmt.Errorf("write cache %q: %w", tmp, err)
+	}
+
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("replace cache %q: %w", c.path, err)
+	}
+
+	return nil
+}
+
+// Revalidated receives whenever a background revalidation finishes, after which loading the same
+// resources again returns what was revalidated. A revalidation that fails drops the stale resource,
+// so that loading it again goes to the client, and reports why it cannot be loaded.
+// Revalidations that finish whilst the last is yet to be received are only received once.
+func (c *Cache) Revalidated() <-chan struct{} {
+	return c.revalidated
+}
+
+// Expire makes everything in the Cache stale, so that it is revalidated the next time it is loaded.
+func (c *Cache) Expire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, e := range c.entries {
+		e.expired = true
+		c.entries[key] = e
+	}
+}
+
+// Revalidating reports whether any stale resource the Cache has returned is still being loaded again,
+// in which case what was returned may be out of date until Revalidated receives.
+func (c *Cache) Revalidating() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.revalidating) > 0
+}
+
+// tooOld reports whether a resource loaded at the passed time is past cacheMaxAge.
+func (c *Cache) tooOld(loaded time.Time) bool {
+	return c.now().Sub(loaded) > cacheMaxAge
+}
+
+// forget drops every entry with one of the passed keys, and anything still being revalidated for them.
+// A key ending in an empty part, and so a separator, is a prefix instead, matching every key with further parts.
+func (c *Cache) forget(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	matches := func(key string) bool {
+		return slices.ContainsFunc(keys, func(k string) bool {
+			return key == k || (strings.HasSuffix(k, "/") && strings.HasPrefix(key, k))
+		})
+	}
+
+	for key := range c.entries {
+		if matches(key) {
+			delete(c.entries, key)
+		}
+	}
+
+	for key := range c.revalidating {
+		if matches(key) {
+			c.forgotten[key] = struct{}{}
+		}
+	}
+}
+
+// cacheKey identifies a resource within the Cache.
+func cacheKey(kind string, parts ...string) string {
+	return kind + "/" + strings.Join(parts, "/")
+}
+
+// cached returns the resource with the passed key, loading it with load if it is not in the Cache.
+// Stale resources are returned as they are, and revalidated in the background.
+func cached[T any](ctx context.Context, c *Cache, key string, ttl time.Duration, load func(context.Context) (T, error)) (T, error) {
+	c.mu.Lock()
+
+	e, ok := c.entries[key]
+	if ok && e.value == nil {
+		var v T
+		if err := json.Unmarshal(e.encoded, &v); err != nil {
+			// Treat it as never having been cached.
+			delete(c.entries, key)
+
+			ok = false
+		} else {
+			e = cacheEntry{value: v, loaded: e.loaded, expired: e.expired}
+			c.entries[key] = e
+		}
+	}
+
+	if ok && c.tooOld(e.loaded) {
+		delete(c.entries, key)
+
+		ok = false
+	}
+
+	if ok {
+		v, _ := e.value.(T)
+
+		if e.expired || c.now().Sub(e.loaded) >= ttl {
+			c.revalidate(ctx, key, func(ctx context.Context) (any, error) { return load(ctx) })
+		}
+
+		c.mu.Unlock()
+
+		return v, nil
+	}
+
+	c.mu.Unlock()
+
+	v, err := load(ctx)
+	if err != nil {
+		var zero T
+
+		return zero, err
+	}
+
+	c.mu.Lock()
+	c.entries[key] = cacheEntry{value: v, loaded: c.now()}
+	c.mu.Unlock()
+
+	return v, nil
+}
+
+// revalidate loads the resource with the passed key again in the background, unless it is already being loaded.
+// It must be called with c.mu held.
+func (c *Cache) revalidate(ctx context.Context, key string, load func(context.Context) (any, error)) {
+	if _, ok := c.revalidating[key]; ok {
+		return
+	}
+
+	c.revalidating[key] = struct{}{}
+
+	// Whoever asked for the resource may not want it for long, but it is still worth having.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), revalidateTimeout)
+
+	go func() {
+		defer cancel()
+
+		v, err := load(ctx)
+
+		c.mu.Lock()
+
+		delete(c.revalidating, key)
+
+		// Whatever forgot it whilst it was loading, such as starting an execution, may have changed it since.
+		_, forgotten := c.forgotten[key]
+		delete(c.forgotten, key)
+
+		switch {
+		case forgotten:
+		case err != nil:
+			delete(c.entries, key)
+		default:
+			c.entries[key] = cacheEntry{value: v, loaded: c.now()}
+		}
+
+		c.mu.Unlock()
+
+		select {
+		case c.revalidated <- struct{}{}:
+		default:
+		}
+	}()
+}
+
+// ListOrganisations returns the organisations, see organisation.Lister.
+func (c *Cache) ListOrganisations(ctx context.Context) ([]organisation.Organisation, error) {
+	orgs, err := cached(ctx, c, cacheKey("organisations"), c.ttls.Organisations, c.client.ListOrganisations)
+
+	return slices.Clone(orgs), err
+}
+
+// ListEnvironments returns the environments of an organisation, see environment.Lister.
+func (c *Cache) ListEnvironments(ctx context.Context, orgID organisation.ID) ([]environment.Environment, error) {
+	envs, err := cached(ctx, c, cacheKey("environments", string(orgID)), c.ttls.Environments,
+		func(ctx context.Context) ([]environment.Environment, error) {
+			return c.client.ListEnvironments(ctx, orgID)
+		})
+
+	return slices.Clone(envs), err
+}
+
+// ListAgents returns the agents of an organisation, see agent.Lister.
+func (c *Cache) ListAgents(ctx context.Context, orgID organisation.ID) ([]agent.Agent, error) {
+	agents, err := cached(ctx, c, cacheKey("agents", string(orgID)), c.ttls.Agents,
+		func(ctx context.Context) ([]agent.Agent, error) {
+			return c.client.ListAgents(ctx, orgID)
+		})
+
+	return slices.Clone(agents), err
+}
+
+// ListWorkflows returns the workflows of an environment, see workflow.Lister.
+func (c *Cache) ListWorkflows(ctx context.Context, orgID organisation.ID, envID environment.ID) ([]workflow.Workflow, error) {
+	workflows, err := cached(ctx, c, cacheKey("workflows", string(orgID), string(envID)), c.ttls.Workflows,
+		func(ctx context.Context) ([]workflow.Workflow, error) {
+			return c.client.ListWorkflows(ctx, orgID, envID)
+		})
+
+	return slices.Clone(workflows), err
+}
+
+// ListExecutions returns a page of the executions of a workflow, see workflow.ExecutionLister.
+func (c *Cache) ListExecutions(
+	ctx context.Context, orgID organisation.ID, envID environment.ID, id workflow.ID, page workflow.Page,
+) ([]workflow.Execution, error) {
+	key := cacheKey("executions", string(orgID), string(envID), string(id), strconv.Itoa(page.Number), strconv.Itoa(page.Size))
+
+	executions, err := cached(ctx, c, key, c.ttls.Executions,
+		func(ctx context.Context) ([]workflow.Execution, error) {
+			return c.client.ListExecutions(ctx, orgID, envID, id, page)
+		})
+
+	return slices.Clone(executions), err
+}
+
+// ListSteps returns the steps of an execution, see workflow.StepLister.
+func (c *Cache) ListSteps(ctx context.Context, orgID organisation.ID, envID environment.ID, id workflow.ExecutionID) ([]workflow.Step, error) {
+	steps, err := cached(ctx, c, cacheKey("steps", string(orgID), string(envID), string(id)), c.ttls.Steps,
+		func(ctx context.Context) ([]workflow.Step, error) {
+			return c.client.ListSteps(ctx, orgID, envID, id)
+		})
+
+	return slices.Clone(steps), err
+}
+
+// GetMetrics returns the metrics of a workflow, see workflow.MetricsGetter.
+func (c *Cache) GetMetrics(ctx context.Context, orgID organisation.ID, envID environment.ID, id workflow.ID) (workflow.Metrics, error) {
+	return cached(ctx, c, cacheKey("metrics", string(orgID), string(envID), string(id)), c.ttls.Metrics,
+		func(ctx context.Context) (workflow.Metrics, error) {
+			return c.client.GetMetrics(ctx, orgID, envID, id)
+		})
+}
+
+// StartExecution starts an execution of a workflow, see workflow.Starter.
+// The workflow's executions, and the environment's workflows, are loaded afresh afterwards to include it.
+func (c *Cache) StartExecution(ctx context.Context, orgID organisation.ID, envID environment.ID, id workflow.ID) (workflow.Execution, error) {
+	e, err := c.client.StartExecution(ctx, orgID, envID, id)
+	if err != nil {
+		// The Cache stands in for the client, so its errors are passed on as they are.
+		return workflow.Execution{}, err //nolint:wrapcheck // See above.
+	}
+
+	c.forget(
+		cacheKey("executions", string(orgID), string(envID), string(id), ""),
+		cacheKey("workflows", string(orgID), string(envID)),
+	)
+
+	return e, nil
+}
+
+// AbortExecution aborts an execution, see workflow.Aborter.
+// The environment's workflows, executions and steps are loaded afresh afterwards, as the execution may belong to any of them.
+func (c *Cache) AbortExecution(ctx context.Context, orgID organisation.ID, envID environment.ID, id workflow.ExecutionID) error {
+	if err := c.client.AbortExecution(ctx, orgID, envID, id); err != nil {
+		return err //nolint:wrapcheck // As for StartExecution.
+	}
+
+	c.forget(
+		cacheKey("executions", string(orgID), string(envID), ""),
+		cacheKey("steps", string(orgID), string(envID), ""),
+		cacheKey("workflows", string(orgID), string(envID)),
+	)
+
+	return nil
+}
+
+// StreamLogs streams the logs of an execution, see workflow.LogStreamer.
+func (c *Cache) StreamLogs(ctx context.Context, orgID organisation.ID, envID environment.ID, id workflow.ExecutionID, lines chan<- workflow.LogLine) error {
+	return c.client.StreamLogs(ctx, orgID, envID, id, lines) //nolint:wrapcheck // As for StartExecution.
+}
+
+var _ client = (*Cache)(nil)
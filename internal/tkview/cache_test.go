@@ -0,0 +1,169 @@
+package tkview
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"tkview/internal/environment"
+)
+
+func TestCacheStartExecutionForgetsOnlyItsEnvironment(t *testing.T) {
+	ctx := context.Background()
+	c := NewCache(&fakeClient{}, DefaultCacheTTLs, "")
+
+	// One environment's ID is a prefix of the other's.
+	const (
+		env  = testEnvA
+		env2 = testEnvA + "2"
+	)
+
+	for _, envID := range []environment.ID{env, env2} {
+		if _, err := c.ListWorkflows(ctx, testOrg, envID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := c.StartExecution(ctx, testOrg, env, testWorkflows[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	got := slices.Sorted(maps.Keys(c.entries))
+	want := []string{cacheKey("workflows", string(testOrg), string(env2))}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("cached after starting an execution = %q, want %q", got, want)
+	}
+}
+
+func TestCacheMaxAge(t *testing.T) {
+	now := time.Now()
+	path := filepath.Join(t.TempDir(), "cache.json")
+
+	saved := map[string]cacheFileEntry{
+		"old":   {Loaded: now.Add(-cacheMaxAge - time.Hour), Value: json.RawMessage(`[]`)},
+		"young": {Loaded: now.Add(-time.Hour), Value: json.RawMessage(`[]`)},
+	}
+
+	b, err := json.Marshal(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewCache(&fakeClient{}, DefaultCacheTTLs, path)
+
+	if got, want := slices.Sorted(maps.Keys(c.entries)), []string{"young"}; !slices.Equal(got, want) {
+		t.Errorf("loaded %q, want %q", got, want)
+	}
+
+	// By the time it is saved again, the young entry is too old as well.
+	c.now = func() time.Time { return now.Add(cacheMaxAge) }
+
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err = os.ReadFile(path) //nolint:gosec // The path is within the test's temporary directory.
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var resaved map[string]cacheFileEntry
+	if err := json.Unmarshal(b, &resaved); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resaved) != 0 {
+		t.Errorf("saved %q, want nothing", slices.Sorted(maps.Keys(resaved)))
+	}
+}
+
+func TestCacheRevalidating(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeClient{}
+	c := NewCache(f, DefaultCacheTTLs, "")
+
+	if _, err := c.ListWorkflows(ctx, testOrg, testEnvA); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Revalidating() {
+		t.Fatal("Revalidating() = true after loading afresh, want false")
+	}
+
+	c.Expire()
+
+	release := f.hold(testEnvA)
+
+	if _, err := c.ListWorkflows(ctx, testOrg, testEnvA); err != nil {
+		t.Fatal(err)
+	}
+
+	<-f.entered
+
+	if !c.Revalidating() {
+		t.Error("Revalidating() = false whilst the expired workflows load again, want true")
+	}
+
+	release()
+
+	select {
+	case <-c.Revalidated():
+	case <-time.After(5 * time.Second):
+		t.Fatal("revalidation did not finish")
+	}
+
+	if c.Revalidating() {
+		t.Error("Revalidating() = true once revalidated, want false")
+	}
+}
+
+func TestCacheRevalidationForgottenWhilstLoading(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeClient{}
+	c := NewCache(f, DefaultCacheTTLs, "")
+
+	if _, err := c.ListWorkflows(ctx, testOrg, testEnvA); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Expire()
+
+	release := f.hold(testEnvA)
+
+	if _, err := c.ListWorkflows(ctx, testOrg, testEnvA); err != nil {
+		t.Fatal(err)
+	}
+
+	<-f.entered
+
+	// Aborting forgets the environment's workflows, whilst the revalidation loads them from before it.
+	if err := c.AbortExecution(ctx, testOrg, testEnvA, testExecutionID(testEnvA, testWorkflows[0], 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	release()
+
+	select {
+	case <-c.Revalidated():
+	case <-time.After(5 * time.Second):
+		t.Fatal("revalidation did not finish")
+	}
+
+	c.mu.Lock()
+	_, ok := c.entries[cacheKey("workflows", string(testOrg), string(testEnvA))]
+	c.mu.Unlock()
+
+	if ok {
+		t.Error("workflows revalidated from before they were forgotten are cached, want them loaded afresh")
+	}
+}
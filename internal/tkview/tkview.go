@@ -2,8 +2,11 @@
 package tkview
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"sync"
 
 	"tkview/internal/agent"
 	"tkview/internal/environment"
@@ -15,8 +18,13 @@ type client interface {
 	agent.Lister
 	environment.Lister
 	organisation.Lister
+	workflow.Aborter
 	workflow.ExecutionLister
 	workflow.Lister
+	workflow.LogStreamer
+	workflow.MetricsGetter
+	workflow.Starter
+	workflow.StepLister
 }
 
 // Organisation is a data structure that can be used to model the nested
@@ -25,6 +33,9 @@ type Organisation struct {
 	organisation.Organisation
 
 	Envs []environment.Environment
+	// Err is set when the environments failed to load, in which case Envs are those last known, if any.
+	// See RefreshEnvironments to try again.
+	Err error
 }
 
 // Execution is a data structure that can be used to model the nested
@@ -32,8 +43,7 @@ type Organisation struct {
 type Execution struct {
 	workflow.Execution
 
-	// TODO: steps
-	// Steps []workflow.Steps
+	Steps []workflow.Step
 }
 
 // Workflow is a data structure that can be used to model the nested
@@ -42,19 +52,34 @@ type Workflow struct {
 	workflow.Workflow
 
 	Executions []Execution
+	// MoreExecutions is set when there may be older executions than those loaded,
+	// see LoadOlderExecutions.
+	MoreExecutions bool
+	// Metrics are nil until loaded, see LoadMetrics.
+	Metrics *workflow.Metrics
 }
 
+// executionPageSize is the number of executions loaded at a time.
+const executionPageSize = 25
+
 // TKView contains the core business logic for the tkview application.
 // The default TKView can be used, however, it will return errors from
 // every function. Instead use New() to create a new instance with
 // a valid client implementation.
+//
+// A TKView is safe for concurrent use. Its state is guarded, and is never held
+// whilst calling the client, so slow requests do not block other callers.
+// Everything returned is a snapshot that the caller is free to modify.
 type TKView struct {
-	client          client
-	orgTree         []Organisation
-	workflowTree    []Workflow
-	currentOrg      organisation.ID
-	currentEnv      environment.ID
-	currentWorkflow workflow.ID
+	client client
+
+	mu               sync.RWMutex
+	orgTree          []Organisation
+	workflowTree     []Workflow
+	currentOrg       organisation.ID
+	currentEnv       environment.ID
+	currentWorkflow  workflow.ID
+	currentExecution workflow.ExecutionID
 }
 
 // New creates a new TKView using the passed client for accessing
@@ -65,53 +90,176 @@ func New(c client) *TKView {
 	}
 }
 
+// revalidator is a client that returns stale data straight away, whilst loading it again in the background.
+// See Cache.
+type revalidator interface {
+	Revalidated() <-chan struct{}
+	Revalidating() bool
+	Expire()
+}
+
+// Revalidated receives whenever the client has loaded stale data again in the background,
+// so that it can be loaded afresh. It is nil if the client always loads afresh.
+func (v *TKView) Revalidated() <-chan struct{} {
+	if r, ok := v.client.(revalidator); ok {
+		return r.Revalidated()
+	}
+
+	return nil
+}
+
+// Revalidating reports whether any stale data the client has returned is still being loaded again.
+// It is always false if the client always loads afresh.
+func (v *TKView) Revalidating() bool {
+	if r, ok := v.client.(revalidator); ok {
+		return r.Revalidating()
+	}
+
+	return false
+}
+
+// Expire makes everything the client has cached stale, so that it is loaded afresh the next time it is asked for.
+// It does nothing if the client does not cache.
+func (v *TKView) Expire() {
+	if r, ok := v.client.(revalidator); ok {
+		r.Expire()
+	}
+}
+
 var (
-	errNoClient         = errors.New("no client")
-	errNoOrgTree        = errors.New("organisations and environments are not populated")
-	errNoOrgOrEnv       = errors.New("no organisation or environment is currently selected")
-	errEnvNotFound      = errors.New("environment not found")
-	errNoWorkflowTree   = errors.New("workflows are not populated")
-	errWorkflowNotFound = errors.New("workflow not found")
-	errNoWorkflow       = errors.New("no workflow is currently selected")
+	errNoClient          = errors.New("no client")
+	errNoOrgTree         = errors.New("organisations and environments are not populated")
+	errNoOrgOrEnv        = errors.New("no organisation or environment is currently selected")
+	errOrgNotFound       = errors.New("organisation not found")
+	errEnvNotFound       = errors.New("environment not found")
+	errNoWorkflowTree    = errors.New("workflows are not populated")
+	errWorkflowNotFound  = errors.New("workflow not found")
+	errNoWorkflow        = errors.New("no workflow is currently selected")
+	errExecutionNotFound = errors.New("execution not found")
+	errNoExecution       = errors.New("no execution is currently selected")
+	// errEnvChanged is a cancellation, as whatever was loading is no longer wanted.
+	errEnvChanged = fmt.Errorf("environment changed whilst loading: %w", context.Canceled)
 )
 
 // GetOrganisationTree updates and then returns the TKView organisation tree.
-func (v *TKView) GetOrganisationTree() ([]Organisation, error) {
+// Only failing to list the organisations is an error, organisations whose environments
+// failed to load are still returned, with their Err set.
+func (v *TKView) GetOrganisationTree(ctx context.Context) ([]Organisation, error) {
 	if v.client == nil {
 		return nil, errNoClient
 	}
 
-	orgs, err := v.client.ListOrganisations()
+	orgs, err := v.client.ListOrganisations(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("list organisations: %w", err)
 	}
 
-	v.orgTree = []Organisation{}
-	for _, org := range orgs {
-		t := Organisation{
-			Organisation: org,
-			Envs:         []environment.Environment{},
+	// Load the environments of several organisations at once, as there may be many of them.
+	var (
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, envConcurrency)
+		orgTree = make([]Organisation, len(orgs))
+	)
+
+	for i, org := range orgs {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			orgTree[i] = v.loadOrganisation(ctx, org)
+		})
+	}
+
+	wg.Wait()
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	// Rather than lose a whole organisation to one failure, keep its last known environments.
+	for i, org := range orgTree {
+		if org.Err == nil {
+			continue
 		}
 
-		envs, err := v.client.ListEnvironments(org.ID)
-		if err != nil {
-			// If we get an error, just blank the current tree to avoid a parietal tree.
-			v.orgTree = []Organisation{}
+		for _, known := range v.orgTree {
+			if known.ID == org.ID {
+				orgTree[i].Envs = known.Envs
+			}
+		}
+	}
+
+	v.orgTree = orgTree
+
+	return slices.Clone(v.orgTree), nil
+}
+
+// RefreshEnvironments loads the environments of the passed organisation again, such as after they failed to load.
+// The updated organisation tree is returned, along with any error loading the environments.
+func (v *TKView) RefreshEnvironments(ctx context.Context, orgID organisation.ID) ([]Organisation, error) {
+	if v.client == nil {
+		return nil, errNoClient
+	}
+
+	v.mu.RLock()
+
+	i := slices.IndexFunc(v.orgTree, func(o Organisation) bool { return o.ID == orgID })
+	if i < 0 {
+		v.mu.RUnlock()
 
-			return nil, fmt.Errorf("list environments for org %q: %w", org.Name, err)
+		return nil, errOrgNotFound
+	}
+
+	org := v.orgTree[i].Organisation
+
+	v.mu.RUnlock()
+
+	loaded := v.loadOrganisation(ctx, org)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for i, known := range v.orgTree {
+		if known.ID != orgID {
+			continue
+		}
+
+		if loaded.Err != nil {
+			loaded.Envs = known.Envs
 		}
 
-		t.Envs = envs
+		v.orgTree[i] = loaded
+	}
+
+	return slices.Clone(v.orgTree), loaded.Err
+}
+
+// envConcurrency limits how many organisations have their environments loaded at once.
+const envConcurrency = 8
 
-		v.orgTree = append(v.orgTree, t)
+// loadOrganisation loads the environments of the passed organisation, recording any failure within it.
+func (v *TKView) loadOrganisation(ctx context.Context, org organisation.Organisation) Organisation {
+	envs, err := v.client.ListEnvironments(ctx, org.ID)
+	if err != nil {
+		return Organisation{
+			Organisation: org,
+			Err:          fmt.Errorf("list environments for org %q: %w", org.Name, err),
+		}
 	}
 
-	return v.orgTree, nil
+	return Organisation{
+		Organisation: org,
+		Envs:         envs,
+	}
 }
 
 // SelectEnvironment checks whether the passed environment ExecutionID is known within the current
 // organisation tree and if it does, it will set it as the currently selected environment.
+// Changing the environment drops the workflow tree, and the selected workflow and execution, as workflows
+// of the same name in different environments share an ID, and must not be merged.
 func (v *TKView) SelectEnvironment(envID environment.ID) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
 	if len(v.orgTree) == 0 {
 		return errNoOrgTree
 	}
@@ -119,6 +267,12 @@ func (v *TKView) SelectEnvironment(envID environment.ID) error {
 	for _, org := range v.orgTree {
 		for _, env := range org.Envs {
 			if env.ID == envID {
+				if v.currentEnv != env.ID {
+					v.workflowTree = nil
+					v.currentWorkflow = ""
+					v.currentExecution = ""
+				}
+
 				v.currentOrg = org.ID
 				v.currentEnv = env.ID
 
@@ -134,6 +288,9 @@ func (v *TKView) SelectEnvironment(envID environment.ID) error {
 // is no longer present in the organisation tree. This may be possible if stale data
 // exists within the TKView model.
 func (v *TKView) GetCurrentEnvironment() (environment.Environment, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
 	if v.currentEnv == "" {
 		return environment.Environment{}, errNoOrgOrEnv
 	}
@@ -151,52 +308,88 @@ func (v *TKView) GetCurrentEnvironment() (environment.Environment, error) {
 	return environment.Environment{}, fmt.Errorf("environment %q not currently known: %w", v.currentEnv, errEnvNotFound)
 }
 
-// GetAgents returns all agents belonging to the organisation parent of the currently selected environment.
-// If no environment is currently selected, it will error.
-func (v *TKView) GetAgents() ([]agent.Agent, error) {
+// selected returns the currently selected organisation and environment, erroring if either are not selected.
+func (v *TKView) selected() (organisation.ID, environment.ID, error) {
 	if v.client == nil {
-		return nil, errNoClient
+		return "", "", errNoClient
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.currentOrg == "" || v.currentEnv == "" {
+		return "", "", errNoOrgOrEnv
+	}
+
+	return v.currentOrg, v.currentEnv, nil
+}
+
+// stillSelected checks that the passed environment is still the currently selected one.
+// It must be called with v.mu held.
+func (v *TKView) stillSelected(envID environment.ID) error {
+	if v.currentEnv != envID {
+		return errEnvChanged
 	}
 
-	if v.currentOrg == "" {
-		return nil, errNoOrgOrEnv
+	return nil
+}
+
+// GetAgents returns all agents belonging to the organisation parent of the currently selected environment.
+// If no environment is currently selected, it will error.
+func (v *TKView) GetAgents(ctx context.Context) ([]agent.Agent, error) {
+	orgID, envID, err := v.selected()
+	if err != nil {
+		return nil, err
 	}
 
-	agents, err := v.client.ListAgents(v.currentOrg)
+	agents, err := v.client.ListAgents(ctx, orgID)
 	if err != nil {
 		return nil, fmt.Errorf("list agents: %w", err)
 	}
 
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	// Agents belong to the organisation, but the environment changing is still a good sign these are unwanted.
+	if err := v.stillSelected(envID); err != nil {
+		return nil, err
+	}
+
 	return agents, nil
 }
 
 // GetWorkflowTree returns all workflows and executions belonging to the
 // currently selected environment and organisation.
 // If no organisation or environment is currently selected, it will error.
-func (v *TKView) GetWorkflowTree() ([]Workflow, error) {
-	if v.client == nil {
-		return nil, errNoClient
-	}
-
-	if v.currentOrg == "" || v.currentEnv == "" {
-		return nil, errNoOrgOrEnv
+func (v *TKView) GetWorkflowTree(ctx context.Context) ([]Workflow, error) {
+	orgID, envID, err := v.selected()
+	if err != nil {
+		return nil, err
 	}
 
-	workflows, err := v.client.ListWorkflows(v.currentOrg, v.currentEnv)
+	workflows, err := v.client.ListWorkflows(ctx, orgID, envID)
 	if err != nil {
 		return nil, fmt.Errorf("list workflows: %w", err)
 	}
 
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if err := v.stillSelected(envID); err != nil {
+		return nil, err
+	}
+
 	ret := make([]Workflow, 0, len(workflows))
 	for _, w := range workflows {
 		wf := Workflow{
 			Workflow: w,
 		}
 
-		// Merge in any already known executions to prevent overfetching.
+		// Merge in any already known executions, and metrics, to prevent overfetching.
 		for _, w := range v.workflowTree {
 			if w.ID == wf.ID {
 				wf.Executions = w.Executions
+				wf.Metrics = w.Metrics
 
 				break
 			}
@@ -207,14 +400,19 @@ func (v *TKView) GetWorkflowTree() ([]Workflow, error) {
 
 	v.workflowTree = ret
 
-	return ret, nil
+	return cloneWorkflows(v.workflowTree), nil
 }
 
 // SelectWorkflow sets the passed workflow as the currently selected workflow.
 // Upon selection the executions for the passed workflow will be populated and
 // so subsequent calls to GetWorkflowTree will have these included.
-func (v *TKView) SelectWorkflow(workflowID workflow.ID) error {
+// Selecting a different workflow to the current one deselects the current execution.
+func (v *TKView) SelectWorkflow(ctx context.Context, workflowID workflow.ID) error {
+	v.mu.Lock()
+
 	if len(v.workflowTree) == 0 {
+		v.mu.Unlock()
+
 		return errNoWorkflowTree
 	}
 
@@ -222,43 +420,258 @@ func (v *TKView) SelectWorkflow(workflowID workflow.ID) error {
 
 	for _, w := range v.workflowTree {
 		if w.ID == workflowID {
+			if v.currentWorkflow != w.ID {
+				v.currentExecution = ""
+			}
+
 			v.currentWorkflow = w.ID
 			found = true
 		}
 	}
 
+	v.mu.Unlock()
+
 	if !found {
 		return errWorkflowNotFound
 	}
 
-	executions, err := v.client.ListExecutions(v.currentOrg, v.currentEnv, v.currentWorkflow)
+	return v.RefreshExecutions(ctx, workflowID)
+}
+
+// RefreshExecutions populates the most recent executions for the passed workflow without changing
+// the current selection, so that subsequent calls to GetWorkflowTree will have these included.
+// Any older executions already loaded by LoadOlderExecutions are kept.
+func (v *TKView) RefreshExecutions(ctx context.Context, workflowID workflow.ID) error {
+	orgID, envID, err := v.selected()
+	if err != nil {
+		return err
+	}
+
+	executions, err := v.client.ListExecutions(ctx, orgID, envID, workflowID, workflow.Page{Number: 0, Size: executionPageSize})
 	if err != nil {
 		return fmt.Errorf("list executions for workflow %q: %w", workflowID, err)
 	}
 
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if err := v.stillSelected(envID); err != nil {
+		return err
+	}
+
+	// Add the executions to the existing tree.
+	for i, w := range v.workflowTree {
+		if w.ID != workflowID {
+			continue
+		}
+
+		ee := withKnownSteps(w.Executions, executions)
+		more := len(executions) == executionPageSize
+
+		// A full page may not reach back as far as the older pages that were already loaded, so keep those.
+		if more && len(ee) > 0 {
+			oldest := ee[len(ee)-1].StartedAt
+
+			for _, known := range w.Executions {
+				if known.StartedAt.Before(oldest) {
+					ee = append(ee, known)
+				}
+			}
+
+			if len(ee) > len(executions) {
+				more = w.MoreExecutions
+			}
+		}
+
+		v.workflowTree[i].Executions = ee
+		v.workflowTree[i].MoreExecutions = more
+
+		break
+	}
+
+	return nil
+}
+
+// LoadOlderExecutions loads the next page of executions of the passed workflow, older than those already loaded,
+// so that subsequent calls to GetWorkflowTree will have these included. The newly loaded executions are returned.
+func (v *TKView) LoadOlderExecutions(ctx context.Context, workflowID workflow.ID) ([]Execution, error) {
+	orgID, envID, err := v.selected()
+	if err != nil {
+		return nil, err
+	}
+
+	v.mu.RLock()
+
+	loaded := -1
+
+	for _, w := range v.workflowTree {
+		if w.ID == workflowID {
+			loaded = len(w.Executions)
+		}
+	}
+
+	v.mu.RUnlock()
+
+	if loaded < 0 {
+		return nil, errWorkflowNotFound
+	}
+
+	// Executions started since the last page was loaded push everything along,
+	// so this page may overlap with what is already loaded, but nothing is skipped.
+	page := workflow.Page{Number: loaded / executionPageSize, Size: executionPageSize}
+
+	executions, err := v.client.ListExecutions(ctx, orgID, envID, workflowID, page)
+	if err != nil {
+		return nil, fmt.Errorf("list executions page %d for workflow %q: %w", page.Number, workflowID, err)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if err := v.stillSelected(envID); err != nil {
+		return nil, err
+	}
+
+	var added []Execution
+
+	for i, w := range v.workflowTree {
+		if w.ID != workflowID {
+			continue
+		}
+
+		for _, e := range executions {
+			if !slices.ContainsFunc(w.Executions, func(known Execution) bool { return known.ID == e.ID }) {
+				added = append(added, Execution{Execution: e})
+			}
+		}
+
+		v.workflowTree[i].Executions = append(slices.Clip(w.Executions), added...)
+		v.workflowTree[i].MoreExecutions = len(executions) == executionPageSize
+
+		break
+	}
+
+	return slices.Clone(added), nil
+}
+
+// withKnownSteps converts the passed executions, merging in any already known steps to prevent overfetching.
+func withKnownSteps(known []Execution, executions []workflow.Execution) []Execution {
 	ee := make([]Execution, 0, len(executions))
+
 	for _, e := range executions {
-		ee = append(ee, Execution{
+		execution := Execution{
 			Execution: e,
+		}
+
+		for _, k := range known {
+			if k.ID == execution.ID {
+				execution.Steps = k.Steps
+
+				break
+			}
+		}
+
+		ee = append(ee, execution)
+	}
+
+	return ee
+}
+
+// metricsConcurrency limits how many workflows have their metrics loaded at once.
+const metricsConcurrency = 8
+
+// LoadMetrics loads the metrics of every workflow in the workflow tree that does not have them yet, so that
+// subsequent calls to GetWorkflowTree will have these included. The updated workflow tree is returned.
+// Metrics that failed to load are left out, and the first error is returned along with whatever did load.
+func (v *TKView) LoadMetrics(ctx context.Context) ([]Workflow, error) {
+	orgID, envID, err := v.selected()
+	if err != nil {
+		return nil, err
+	}
+
+	v.mu.RLock()
+
+	var ids []workflow.ID
+
+	for _, w := range v.workflowTree {
+		if w.Metrics == nil {
+			ids = append(ids, w.ID)
+		}
+	}
+
+	v.mu.RUnlock()
+
+	var (
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, metricsConcurrency)
+		loadedMu sync.Mutex
+		loaded   = make(map[workflow.ID]workflow.Metrics, len(ids))
+		errs     = make([]error, len(ids))
+	)
+
+	for i, id := range ids {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			m, err := v.client.GetMetrics(ctx, orgID, envID, id)
+			if err != nil {
+				errs[i] = fmt.Errorf("get metrics of workflow %q: %w", id, err)
+				return
+			}
+
+			loadedMu.Lock()
+			loaded[id] = m
+			loadedMu.Unlock()
 		})
 	}
 
-	// Add the executions to the existing tree.
+	wg.Wait()
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if err := v.stillSelected(envID); err != nil {
+		return nil, err
+	}
+
 	for i, w := range v.workflowTree {
-		if w.ID == workflowID {
-			v.workflowTree[i].Executions = ee
+		if m, ok := loaded[w.ID]; ok {
+			v.workflowTree[i].Metrics = &m
+		}
+	}
 
-			break
+	ret := cloneWorkflows(v.workflowTree)
+
+	for _, err := range errs {
+		if err != nil {
+			return ret, err
 		}
 	}
 
-	return nil
+	return ret, nil
 }
 
 // GetCurrentWorkflow returns the currently selected workflow, unless the workflow
 // is no longer present in the workflow tree. This may be possible if stale data
 // exists within the TKView model.
 func (v *TKView) GetCurrentWorkflow() (Workflow, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	w, err := v.currentWorkflowLocked()
+	if err != nil {
+		return Workflow{}, err
+	}
+
+	w.Executions = slices.Clone(w.Executions)
+
+	return w, nil
+}
+
+// currentWorkflowLocked is GetCurrentWorkflow without the locking or copying.
+// It must be called with v.mu held.
+func (v *TKView) currentWorkflowLocked() (Workflow, error) {
 	if v.currentWorkflow == "" {
 		return Workflow{}, errNoWorkflow
 	}
@@ -273,3 +686,187 @@ func (v *TKView) GetCurrentWorkflow() (Workflow, error) {
 	// TODO: maybe try repopulating and then selecting again?
 	return Workflow{}, fmt.Errorf("workflow %q not currently known: %w", v.currentWorkflow, errWorkflowNotFound)
 }
+
+// StartExecution starts a new execution of the passed workflow within the currently
+// selected organisation and environment. The new execution is added to the front of
+// the workflow's executions so that subsequent calls to GetWorkflowTree include it.
+func (v *TKView) StartExecution(ctx context.Context, workflowID workflow.ID) (Execution, error) {
+	orgID, envID, err := v.selected()
+	if err != nil {
+		return Execution{}, err
+	}
+
+	v.mu.RLock()
+	known := slices.ContainsFunc(v.workflowTree, func(w Workflow) bool {
+		return w.ID == workflowID
+	})
+	v.mu.RUnlock()
+
+	if !known {
+		return Execution{}, errWorkflowNotFound
+	}
+
+	e, err := v.client.StartExecution(ctx, orgID, envID, workflowID)
+	if err != nil {
+		return Execution{}, fmt.Errorf("start execution for workflow %q: %w", workflowID, err)
+	}
+
+	execution := Execution{
+		Execution: e,
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	// The execution has started regardless, so only skip adding it to the tree if it no longer belongs there.
+	if v.stillSelected(envID) != nil {
+		return execution, nil
+	}
+
+	for i, w := range v.workflowTree {
+		if w.ID == workflowID {
+			v.workflowTree[i].Executions = append([]Execution{execution}, w.Executions...)
+
+			break
+		}
+	}
+
+	return execution, nil
+}
+
+// SelectExecution sets the passed execution as the currently selected execution.
+// The execution must belong to the currently selected workflow.
+// Upon selection the steps for the passed execution will be populated and
+// so subsequent calls to GetWorkflowTree will have these included.
+func (v *TKView) SelectExecution(ctx context.Context, executionID workflow.ExecutionID) error {
+	orgID, envID, err := v.selected()
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+
+	w, err := v.currentWorkflowLocked()
+	if err != nil {
+		v.mu.Unlock()
+
+		return err
+	}
+
+	found := false
+
+	for _, e := range w.Executions {
+		if e.ID == executionID {
+			v.currentExecution = e.ID
+			found = true
+		}
+	}
+
+	v.mu.Unlock()
+
+	if !found {
+		return fmt.Errorf("execution %q not currently known: %w", executionID, errExecutionNotFound)
+	}
+
+	steps, err := v.client.ListSteps(ctx, orgID, envID, executionID)
+	if err != nil {
+		return fmt.Errorf("list steps for execution %q: %w", executionID, err)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if err := v.stillSelected(envID); err != nil {
+		return err
+	}
+
+	// Add the steps to the existing tree.
+	// The workflow is searched for again, as the current workflow may have changed whilst loading.
+	for i, w := range v.workflowTree {
+		for j, e := range w.Executions {
+			if e.ID == executionID {
+				v.workflowTree[i].Executions[j].Steps = steps
+
+				return nil
+			}
+		}
+	}
+
+	return nil
+}
+
+// DeselectExecution clears the currently selected execution, leaving only the workflow selected.
+func (v *TKView) DeselectExecution() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.currentExecution = ""
+}
+
+// GetCurrentExecution returns the currently selected execution, unless the execution
+// is no longer present in the workflow tree. This may be possible if stale data
+// exists within the TKView model.
+func (v *TKView) GetCurrentExecution() (Execution, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.currentExecution == "" {
+		return Execution{}, errNoExecution
+	}
+
+	w, err := v.currentWorkflowLocked()
+	if err != nil {
+		return Execution{}, err
+	}
+
+	for _, e := range w.Executions {
+		if e.ID == v.currentExecution {
+			return e, nil
+		}
+	}
+
+	return Execution{}, fmt.Errorf("execution %q not currently known: %w", v.currentExecution, errExecutionNotFound)
+}
+
+// AbortExecution aborts the passed execution within the currently selected
+// organisation and environment.
+func (v *TKView) AbortExecution(ctx context.Context, executionID workflow.ExecutionID) error {
+	orgID, envID, err := v.selected()
+	if err != nil {
+		return err
+	}
+
+	if err := v.client.AbortExecution(ctx, orgID, envID, executionID); err != nil {
+		return fmt.Errorf("abort execution %q: %w", executionID, err)
+	}
+
+	return nil
+}
+
+// StreamExecutionLogs sends the output of the passed execution within the currently selected
+// organisation and environment to lines. It blocks until the output is exhausted, or the passed
+// context is cancelled.
+func (v *TKView) StreamExecutionLogs(ctx context.Context, executionID workflow.ExecutionID, lines chan<- workflow.LogLine) error {
+	orgID, envID, err := v.selected()
+	if err != nil {
+		return err
+	}
+
+	if err := v.client.StreamLogs(ctx, orgID, envID, executionID, lines); err != nil {
+		return fmt.Errorf("stream logs for execution %q: %w", executionID, err)
+	}
+
+	return nil
+}
+
+// cloneWorkflows copies the passed workflows, along with their executions, so that
+// they can be modified without affecting the originals. Steps are never modified
+// in place, only replaced, and so do not need copying.
+func cloneWorkflows(workflows []Workflow) []Workflow {
+	ret := slices.Clone(workflows)
+	for i := range ret {
+		ret[i].Executions = slices.Clone(ret[i].Executions)
+	}
+
+	return ret
+}
@@ -0,0 +1,396 @@
+package tkview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"tkview/internal/agent"
+	"tkview/internal/environment"
+	"tkview/internal/organisation"
+	"tkview/internal/workflow"
+)
+
+const (
+	testOrg  organisation.ID = "org"
+	testEnvA environment.ID  = "envA"
+	testEnvB environment.ID  = "envB"
+)
+
+// testWorkflows are in every environment, with the same IDs, as workflows are identified by name.
+var testWorkflows = []workflow.ID{"smoke", "e2e"}
+
+// fakeClient serves the same workflows in every environment, with executions identified by the environment
+// they belong to, so that any leaking into another environment can be spotted. Calls for the held environment
+// block until released, so that the environment can be changed whilst they are loading.
+type fakeClient struct {
+	mu      sync.Mutex
+	held    environment.ID
+	entered chan struct{}
+	release chan struct{}
+	// yield makes every call give way to other goroutines, to shake out races.
+	yield bool
+}
+
+var _ client = &fakeClient{}
+
+// hold makes calls for the passed environment block until the returned function is called.
+// Each blocked call is sent to entered.
+func (f *fakeClient) hold(envID environment.ID) func() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.held = envID
+	f.entered = make(chan struct{}, 1)
+	f.release = make(chan struct{})
+
+	release := f.release
+
+	return func() {
+		f.mu.Lock()
+		f.held = ""
+		f.mu.Unlock()
+
+		close(release)
+	}
+}
+
+// wait blocks whilst the passed environment is held.
+func (f *fakeClient) wait(ctx context.Context, envID environment.ID) error {
+	if f.yield {
+		runtime.Gosched()
+	}
+
+	f.mu.Lock()
+	held, entered, release := f.held, f.entered, f.release
+	f.mu.Unlock()
+
+	if held == "" || held != envID {
+		return nil
+	}
+
+	select {
+	case entered <- struct{}{}:
+	default:
+	}
+
+	select {
+	case <-release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *fakeClient) ListOrganisations(context.Context) ([]organisation.Organisation, error) {
+	return []organisation.Organisation{{ID: testOrg, Name: "org"}}, nil
+}
+
+func (f *fakeClient) ListEnvironments(context.Context, organisation.ID) ([]environment.Environment, error) {
+	return []environment.Environment{
+		{ID: testEnvA, Name: "a"},
+		{ID: testEnvB, Name: "b"},
+	}, nil
+}
+
+func (f *fakeClient) ListAgents(context.Context, organisation.ID) ([]agent.Agent, error) {
+	return nil, nil
+}
+
+func (f *fakeClient) ListWorkflows(ctx context.Context, _ organisation.ID, envID environment.ID) ([]workflow.Workflow, error) {
+	if err := f.wait(ctx, envID); err != nil {
+		return nil, err
+	}
+
+	workflows := make([]workflow.Workflow, 0, len(testWorkflows))
+	for _, id := range testWorkflows {
+		workflows = append(workflows, workflow.Workflow{ID: id, Name: string(id)})
+	}
+
+	return workflows, nil
+}
+
+func (f *fakeClient) ListExecutions(
+	ctx context.Context, _ organisation.ID, envID environment.ID, id workflow.ID, page workflow.Page,
+) ([]workflow.Execution, error) {
+	if err := f.wait(ctx, envID); err != nil {
+		return nil, err
+	}
+
+	const executions = 3
+
+	ee := make([]workflow.Execution, 0, executions)
+	for i := range executions {
+		n := page.Number*page.Size + i
+		ee = append(ee, workflow.Execution{
+			ID:        testExecutionID(envID, id, n),
+			StartedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).Add(-time.Duration(n) * time.Minute),
+		})
+	}
+
+	return ee, nil
+}
+
+func testExecutionID(envID environment.ID, id workflow.ID, n int) workflow.ExecutionID {
+	return workflow.ExecutionID(fmt.Sprintf("%s/%s/%d", envID, id, n))
+}
+
+func (f *fakeClient) ListSteps(ctx context.Context, _ organisation.ID, envID environment.ID, _ workflow.ExecutionID) ([]workflow.Step, error) {
+	if err := f.wait(ctx, envID); err != nil {
+		return nil, err
+	}
+
+	return []workflow.Step{{ID: workflow.StepID(envID)}}, nil
+}
+
+func (f *fakeClient) GetMetrics(ctx context.Context, _ organisation.ID, envID environment.ID, _ workflow.ID) (workflow.Metrics, error) {
+	if err := f.wait(ctx, envID); err != nil {
+		return workflow.Metrics{}, err
+	}
+
+	// The number of executions identifies the environment.
+	return workflow.Metrics{Executions: len(envID)}, nil
+}
+
+func (f *fakeClient) StartExecution(ctx context.Context, _ organisation.ID, envID environment.ID, id workflow.ID) (workflow.Execution, error) {
+	if err := f.wait(ctx, envID); err != nil {
+		return workflow.Execution{}, err
+	}
+
+	return workflow.Execution{ID: testExecutionID(envID, id, -1)}, nil
+}
+
+func (f *fakeClient) AbortExecution(context.Context, organisation.ID, environment.ID, workflow.ExecutionID) error {
+	return nil
+}
+
+func (f *fakeClient) StreamLogs(context.Context, organisation.ID, environment.ID, workflow.ExecutionID, chan<- workflow.LogLine) error {
+	return nil
+}
+
+// newTestTKView returns a TKView with envA selected, and its workflows and executions loaded.
+func newTestTKView(t *testing.T, f *fakeClient) *TKView {
+	t.Helper()
+
+	ctx := context.Background()
+	v := New(f)
+
+	if _, err := v.GetOrganisationTree(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := v.SelectEnvironment(testEnvA); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := v.GetWorkflowTree(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range testWorkflows {
+		if err := v.RefreshExecutions(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return v
+}
+
+// checkWorkflows fails if anything in the passed workflows does not belong to the passed environment.
+func checkWorkflows(t *testing.T, envID environment.ID, workflows []Workflow) {
+	t.Helper()
+
+	for _, w := range workflows {
+		for _, e := range w.Executions {
+			if !strings.HasPrefix(string(e.ID), string(envID)+"/") {
+				t.Errorf("workflow %q in %q has execution %q of another environment", w.ID, envID, e.ID)
+			}
+
+			for _, s := range e.Steps {
+				if s.ID != workflow.StepID(envID) {
+					t.Errorf("execution %q in %q has step %q of another environment", e.ID, envID, s.ID)
+				}
+			}
+		}
+
+		if w.Metrics != nil && w.Metrics.Executions != len(envID) {
+			t.Errorf("workflow %q in %q has metrics %+v of another environment", w.ID, envID, *w.Metrics)
+		}
+	}
+}
+
+func TestSelectEnvironmentWhilstLoading(t *testing.T) {
+	tests := []struct {
+		name string
+		load func(ctx context.Context, v *TKView) error
+	}{
+		{
+			name: "GetWorkflowTree",
+			load: func(ctx context.Context, v *TKView) error {
+				_, err := v.GetWorkflowTree(ctx)
+				return err
+			},
+		},
+		{
+			name: "SelectWorkflow",
+			load: func(ctx context.Context, v *TKView) error {
+				return v.SelectWorkflow(ctx, "smoke")
+			},
+		},
+		{
+			name: "RefreshExecutions",
+			load: func(ctx context.Context, v *TKView) error {
+				return v.RefreshExecutions(ctx, "smoke")
+			},
+		},
+		{
+			name: "LoadOlderExecutions",
+			load: func(ctx context.Context, v *TKView) error {
+				_, err := v.LoadOlderExecutions(ctx, "smoke")
+				return err
+			},
+		},
+		{
+			name: "LoadMetrics",
+			load: func(ctx context.Context, v *TKView) error {
+				_, err := v.LoadMetrics(ctx)
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := &fakeClient{}
+			v := newTestTKView(t, f)
+
+			release := f.hold(testEnvA)
+			errs := make(chan error)
+
+			go func() { errs <- tt.load(ctx, v) }()
+
+			<-f.entered
+
+			if err := v.SelectEnvironment(testEnvB); err != nil {
+				t.Fatal(err)
+			}
+
+			release()
+
+			if err := <-errs; !errors.Is(err, errEnvChanged) {
+				t.Fatalf("loading whilst the environment changed: error = %v, want %v", err, errEnvChanged)
+			}
+
+			if !errors.Is(errEnvChanged, context.Canceled) {
+				t.Error("errEnvChanged is not a cancellation")
+			}
+
+			// Nothing loaded for envA may show up in envB, even though its workflows have the same IDs.
+			workflows, err := v.GetWorkflowTree(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			checkWorkflows(t, testEnvB, workflows)
+
+			if err := v.SelectWorkflow(ctx, "smoke"); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := v.LoadMetrics(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			w, err := v.GetCurrentWorkflow()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			checkWorkflows(t, testEnvB, []Workflow{w})
+		})
+	}
+}
+
+func TestSelectEnvironmentConcurrently(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeClient{yield: true}
+	v := newTestTKView(t, f)
+
+	// Whilst the environment keeps changing, everything either loads for the environment it was asked for,
+	// or is rejected, and no snapshot ever mixes the two environments.
+	var wg sync.WaitGroup
+
+	wg.Go(func() {
+		for i := range 200 {
+			envID := testEnvA
+			if i%2 == 1 {
+				envID = testEnvB
+			}
+
+			if err := v.SelectEnvironment(envID); err != nil {
+				t.Error(err)
+			}
+
+			runtime.Gosched()
+		}
+	})
+
+	for range 4 {
+		wg.Go(func() {
+			for range 100 {
+				workflows, err := v.GetWorkflowTree(ctx)
+				if err != nil && !errors.Is(err, errEnvChanged) {
+					t.Errorf("GetWorkflowTree() error = %v", err)
+				}
+
+				checkSameEnvironment(t, workflows)
+
+				for _, id := range testWorkflows {
+					// The workflow tree may be empty, as it is dropped whenever the environment changes.
+					err := v.SelectWorkflow(ctx, id)
+					if err != nil && !errors.Is(err, errEnvChanged) && !errors.Is(err, errNoWorkflowTree) {
+						t.Errorf("SelectWorkflow(%q) error = %v", id, err)
+					}
+
+					if err := v.RefreshExecutions(ctx, id); err != nil && !errors.Is(err, errEnvChanged) {
+						t.Errorf("RefreshExecutions(%q) error = %v", id, err)
+					}
+				}
+			}
+		})
+	}
+
+	wg.Wait()
+
+	// Once settled, only the final environment is seen.
+	if err := v.SelectEnvironment(testEnvB); err != nil {
+		t.Fatal(err)
+	}
+
+	workflows, err := v.GetWorkflowTree(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkWorkflows(t, testEnvB, workflows)
+}
+
+// checkSameEnvironment fails if the passed workflows have executions of more than one environment.
+func checkSameEnvironment(t *testing.T, workflows []Workflow) {
+	t.Helper()
+
+	for _, w := range workflows {
+		for _, e := range w.Executions {
+			envID, _, _ := strings.Cut(string(e.ID), "/")
+			checkWorkflows(t, environment.ID(envID), workflows)
+
+			return
+		}
+	}
+}
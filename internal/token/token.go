@@ -0,0 +1,155 @@
+// Package token provides the sources from which an API token can be found.
+package token
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Source should provide an API token whenever one is required.
+type Source interface {
+	Token(ctx context.Context) (string, error)
+}
+
+var (
+	_ Source      = Static("")
+	_ Source      = Env("")
+	_ Source      = File("")
+	_ Source      = Keyring{}
+	_ Source      = &cache{}
+	_ Invalidator = &cache{}
+)
+
+// Invalidator is a Source that remembers tokens, which should be told when a token it provided is rejected,
+// so that it asks for the token afresh, such as after it has been rotated.
+type Invalidator interface {
+	Invalidate(token string)
+}
+
+var (
+	errEmpty  = errors.New("token is empty")
+	errNotSet = errors.New("environment variable is not set")
+)
+
+// Static is a token that is known upfront.
+type Static string
+
+// Token returns the static token.
+func (s Static) Token(context.Context) (string, error) {
+	return clean(string(s))
+}
+
+// Env is the name of an environment variable holding a token.
+type Env string
+
+// Token returns the contents of the environment variable.
+func (e Env) Token(context.Context) (string, error) {
+	v, ok := os.LookupEnv(string(e))
+	if !ok {
+		return "", fmt.Errorf("%q: %w", string(e), errNotSet)
+	}
+
+	return clean(v)
+}
+
+// File is the path of a file holding a token.
+// The file is read every time a token is required, so that the token may be rotated.
+type File string
+
+// Token returns the contents of the file.
+func (f File) Token(context.Context) (string, error) {
+	b, err := os.ReadFile(string(f))
+	if err != nil {
+		return "", fmt.Errorf("read token file: %w", err)
+	}
+
+	return clean(string(b))
+}
+
+// DefaultKeyringCommand is the command used to look up tokens in the OS keyring.
+// It is provided by libsecret, and uses the Secret Service API.
+const DefaultKeyringCommand = "secret-tool"
+
+// Keyring is a token stored in the OS keyring, and looked up using the Secret Service API.
+// Tokens can be stored in the keyring using:
+//
+//	secret-tool store --label tkview service <Service> account <Account>
+type Keyring struct {
+	Service string
+	Account string
+	// Command is a secret-tool compatible command used to look up the token.
+	// If empty, then DefaultKeyringCommand is used. Setting this allows using a fake keyring.
+	Command string
+}
+
+// Token looks up the token within the keyring.
+func (k Keyring) Token(ctx context.Context) (string, error) {
+	command := k.Command
+	if command == "" {
+		command = DefaultKeyringCommand
+	}
+
+	//nolint:gosec // The command is intentionally configurable so that other secret-tool implementations can be used.
+	out, err := exec.CommandContext(ctx, command, "lookup", "service", k.Service, "account", k.Account).Output()
+	if err != nil {
+		return "", fmt.Errorf("look up keyring token for service %q and account %q: %w", k.Service, k.Account, err)
+	}
+
+	return clean(string(out))
+}
+
+// Cache wraps the passed source, so that once a token has been successfully provided it is reused,
+// until it is rejected, see Invalidator. This is useful for sources that are expensive to ask, such as Keyring.
+func Cache(s Source) Source {
+	return &cache{source: s}
+}
+
+type cache struct {
+	source Source
+	mu     sync.Mutex
+	token  string
+}
+
+// Token returns the cached token, asking the wrapped source only if there is not one yet.
+func (c *cache) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" {
+		return c.token, nil
+	}
+
+	t, err := c.source.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("get token to cache: %w", err)
+	}
+
+	c.token = t
+
+	return t, nil
+}
+
+// Invalidate forgets the cached token if it is the passed token, so that the next is asked for afresh.
+// Any other token is left alone, as it may already be the replacement.
+func (c *cache) Invalidate(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token == token {
+		c.token = ""
+	}
+}
+
+func clean(t string) (string, error) {
+	t = strings.TrimSpace(t)
+	if t == "" {
+		return "", errEmpty
+	}
+
+	return t, nil
+}
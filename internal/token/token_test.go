@@ -0,0 +1,177 @@
+package token_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tkview/internal/token"
+)
+
+// fakeKeyringEnv names the file of "service account token" lines the test binary looks tokens up in,
+// when it is run as a fake secret-tool.
+const fakeKeyringEnv = "TKVIEW_FAKE_KEYRING"
+
+func TestMain(m *testing.M) {
+	if path, ok := os.LookupEnv(fakeKeyringEnv); ok {
+		os.Exit(fakeKeyring(path, os.Args[1:]))
+	}
+
+	os.Exit(m.Run())
+}
+
+// fakeKeyring behaves as "secret-tool lookup service <service> account <account>" would,
+// printing the token and exiting 1 when there is not one.
+func fakeKeyring(path string, args []string) int {
+	const lookupArgs = 5
+	if len(args) != lookupArgs || args[0] != "lookup" || args[1] != "service" || args[3] != "account" {
+		fmt.Fprintf(os.Stderr, "unexpected arguments: %q\n", args)
+
+		return 2
+	}
+
+	f, err := os.Open(path) //nolint:gosec // The path is written by the test.
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+
+		return 1
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if service, rest, _ := strings.Cut(s.Text(), " "); service == args[2] {
+			if account, t, _ := strings.Cut(rest, " "); account == args[4] {
+				fmt.Println(t) //nolint:forbidigo // This is the output of the fake command.
+
+				return 0
+			}
+		}
+	}
+
+	return 1
+}
+
+// newFakeKeyring returns a Keyring looking tokens up in a fake keyring, and a function to store tokens in it.
+func newFakeKeyring(t *testing.T) (token.Keyring, func(service, account, token string)) {
+	t.Helper()
+
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "keyring")
+	t.Setenv(fakeKeyringEnv, path)
+
+	store := func(service, account, token string) {
+		t.Helper()
+
+		line := fmt.Sprintf("%s %s %s\n", service, account, token)
+		if err := os.WriteFile(path, []byte(line), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return token.Keyring{Service: "tkview", Account: "me", Command: exe}, store
+}
+
+func TestSources(t *testing.T) {
+	dir := t.TempDir()
+
+	file := filepath.Join(dir, "token")
+	if err := os.WriteFile(file, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	empty := filepath.Join(dir, "empty")
+	if err := os.WriteFile(empty, []byte(" \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("TKVIEW_TEST_TOKEN", " from-env ")
+
+	keyring, store := newFakeKeyring(t)
+	store("tkview", "me", "from-keyring")
+
+	tests := []struct {
+		name    string
+		source  token.Source
+		want    string
+		wantErr bool
+	}{
+		{name: "static", source: token.Static("static"), want: "static"},
+		{name: "empty static", source: token.Static(""), wantErr: true},
+		{name: "env", source: token.Env("TKVIEW_TEST_TOKEN"), want: "from-env"},
+		{name: "unset env", source: token.Env("TKVIEW_TEST_UNSET"), wantErr: true},
+		{name: "file", source: token.File(file), want: "from-file"},
+		{name: "empty file", source: token.File(empty), wantErr: true},
+		{name: "missing file", source: token.File(filepath.Join(dir, "missing")), wantErr: true},
+		{name: "keyring", source: keyring, want: "from-keyring"},
+		{name: "missing keyring entry", source: token.Keyring{Service: "other", Account: "me", Command: keyring.Command}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.source.Token(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Token() error = %v, want error %t", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("Token() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	keyring, store := newFakeKeyring(t)
+	store("tkview", "me", "first")
+
+	c := token.Cache(keyring)
+
+	get := func() string {
+		t.Helper()
+
+		got, err := c.Token(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return got
+	}
+
+	if got := get(); got != "first" {
+		t.Fatalf("Token() = %q, want first", got)
+	}
+
+	// The token is rotated, but the cached token is used until it is rejected.
+	store("tkview", "me", "second")
+
+	if got := get(); got != "first" {
+		t.Fatalf("Token() after rotating = %q, want the cached first", got)
+	}
+
+	inv, ok := c.(token.Invalidator)
+	if !ok {
+		t.Fatal("Cache() is not an Invalidator")
+	}
+
+	// A rejection of some other token, such as one already replaced, leaves the cached token alone.
+	inv.Invalidate("other")
+
+	if got := get(); got != "first" {
+		t.Fatalf("Token() after invalidating another token = %q, want first", got)
+	}
+
+	inv.Invalidate("first")
+
+	if got := get(); got != "second" {
+		t.Fatalf("Token() after invalidating = %q, want the rotated second", got)
+	}
+}
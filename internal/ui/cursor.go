@@ -0,0 +1,299 @@
+package ui
+
+import (
+	"tkview/internal/environment"
+	"tkview/internal/organisation"
+	"tkview/internal/workflow"
+
+	"github.com/charmbracelet/bubbletea/v2"
+)
+
+// rowKind is the kind of thing shown by a row of a tree.
+type rowKind int
+
+const (
+	rowOrganisation rowKind = iota
+	rowEnvironment
+	rowWorkflow
+	rowExecution
+	// rowOlderExecutions stands in for the executions of a workflow that are yet to be loaded.
+	rowOlderExecutions
+)
+
+// row is a line of a tree that the cursor can be on.
+// Only the identifiers relevant to its kind, and those of its parents, are set.
+type row struct {
+	kind      rowKind
+	org       organisation.ID
+	env       environment.ID
+	workflow  workflow.ID
+	execution workflow.ExecutionID
+}
+
+// rows returns the rows of the focused tree, in the order they are displayed.
+// Rows hidden within collapsed parents are not included.
+func (m Model) rows() []row {
+	switch m.focused {
+	case viewEnvs:
+		return m.envRows()
+	case viewWorkflows:
+		return m.workflowRows()
+	case viewAgents:
+	}
+
+	return nil
+}
+
+func (m Model) envRows() []row {
+	var rows []row
+
+	for _, org := range m.visibleOrgs() {
+		// Organisations without environments have nothing to select, so the cursor skips them,
+		// unless their environments failed to load, when they can be retried.
+		if len(org.Envs) == 0 && org.Err == nil {
+			continue
+		}
+
+		rows = append(rows, row{kind: rowOrganisation, org: org.ID})
+
+		if _, collapsed := m.collapsedOrgs[org.ID]; collapsed {
+			continue
+		}
+
+		for _, env := range org.Envs {
+			rows = append(rows, row{kind: rowEnvironment, org: org.ID, env: env.ID})
+		}
+	}
+
+	return rows
+}
+
+func (m Model) workflowRows() []row {
+	spans := m.workflowSpans()
+
+	rows := make([]row, len(spans))
+	for i, s := range spans {
+		rows[i] = s.row
+	}
+
+	return rows
+}
+
+// firstEnvironment returns the first environment of any organisation, skipping those without environments.
+func (m Model) firstEnvironment() (environment.ID, bool) {
+	for _, org := range m.orgs {
+		if len(org.Envs) > 0 {
+			return org.Envs[0].ID, true
+		}
+	}
+
+	return "", false
+}
+
+// currentRow returns the row of the focused tree that the cursor is on.
+// The cursor follows whatever is selected in tkview, apart from organisations, which cannot be selected there.
+// Instead, orgCursor is set whilst the cursor is on an organisation.
+func (m Model) currentRow() (row, bool) {
+	switch m.focused {
+	case viewEnvs:
+		if m.orgCursor != "" {
+			return row{kind: rowOrganisation, org: m.orgCursor}, true
+		}
+
+		env, err := m.tkview.GetCurrentEnvironment()
+		if err != nil {
+			return row{}, false
+		}
+
+		for _, org := range m.orgs {
+			for _, e := range org.Envs {
+				if e.ID == env.ID {
+					return row{kind: rowEnvironment, org: org.ID, env: env.ID}, true
+				}
+			}
+		}
+	case viewWorkflows:
+		return m.currentWorkflowRow()
+	case viewAgents:
+	}
+
+	return row{}, false
+}
+
+// currentWorkflowRow returns the row of the workflow tree that its cursor is on, whether or not it is focused.
+func (m Model) currentWorkflowRow() (row, bool) {
+	wf, err := m.tkview.GetCurrentWorkflow()
+	if err != nil {
+		return row{}, false
+	}
+
+	if e, err := m.tkview.GetCurrentExecution(); err == nil {
+		return row{kind: rowExecution, workflow: wf.ID, execution: e.ID}, true
+	}
+
+	return row{kind: rowWorkflow, workflow: wf.ID}, true
+}
+
+// cursorIndex returns the index of the current row within rows, or -1 if it is not there.
+func (m Model) cursorIndex(rows []row) int {
+	current, ok := m.currentRow()
+	if !ok {
+		return -1
+	}
+
+	for i, r := range rows {
+		if r == current {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// moveCursor moves the cursor by delta rows of the focused tree.
+// Moving past either end wraps around when wrap is set, otherwise the cursor stops at the end.
+func (m Model) moveCursor(delta int, wrap bool) (tea.Model, tea.Cmd) {
+	rows := m.rows()
+	if len(rows) == 0 {
+		return m, nil
+	}
+
+	target := 0
+	if i := m.cursorIndex(rows); i >= 0 {
+		target = i + delta
+	}
+
+	if wrap {
+		target = (target%len(rows) + len(rows)) % len(rows)
+	} else {
+		target = min(max(target, 0), len(rows)-1)
+	}
+
+	// Older executions are only loaded by stepping onto them from the last loaded execution,
+	// landing on them in any other way would select something from another workflow.
+	if rows[target].kind == rowOlderExecutions && delta != 1 {
+		target--
+	}
+
+	return m.moveTo(rows[target])
+}
+
+// moveToFirst moves the cursor to the first row of the focused tree.
+func (m Model) moveToFirst() (tea.Model, tea.Cmd) {
+	rows := m.rows()
+	if len(rows) == 0 {
+		return m, nil
+	}
+
+	return m.moveTo(rows[0])
+}
+
+// moveToLast moves the cursor to the last row of the focused tree.
+func (m Model) moveToLast() (tea.Model, tea.Cmd) {
+	return m.moveCursor(len(m.rows()), false)
+}
+
+// moveTo moves the cursor to the passed row, selecting whatever it shows.
+func (m Model) moveTo(r row) (tea.Model, tea.Cmd) {
+	current, _ := m.currentRow()
+	if r == current {
+		return m, nil
+	}
+
+	switch r.kind {
+	case rowOrganisation:
+		m.orgCursor = r.org
+
+		return m, nil
+	case rowEnvironment:
+		m.orgCursor = ""
+
+		// Coming from an organisation, the environment may already be selected.
+		if env, err := m.tkview.GetCurrentEnvironment(); err == nil && env.ID == r.env {
+			return m, nil
+		}
+
+		return m, switchEnvCmd(r.env)
+	case rowWorkflow:
+		if current.workflow == r.workflow {
+			return m, func() tea.Msg { return deselectExecutionMsg{} }
+		}
+
+		return m, switchWorkflowCmd(r.workflow)
+	case rowExecution:
+		if current.workflow == r.workflow {
+			return m, func() tea.Msg { return executionMsg(r.execution) }
+		}
+
+		ctx := m.startLoad(loadWorkflow, loadExecution, loadOlderExecutions)
+
+		return m, m.selectWorkflowExecution(ctx, r.workflow, r.execution)
+	case rowOlderExecutions:
+		return m, func() tea.Msg { return olderExecutionsMsg(r.workflow) }
+	}
+
+	return m, nil
+}
+
+// expand expands the row under the cursor, or if it is already expanded, moves to its first child.
+func (m Model) expand() (tea.Model, tea.Cmd) {
+	current, ok := m.currentRow()
+	if !ok {
+		return m, nil
+	}
+
+	switch current.kind {
+	case rowOrganisation:
+		if _, collapsed := m.collapsedOrgs[current.org]; collapsed {
+			delete(m.collapsedOrgs, current.org)
+
+			return m, nil
+		}
+	case rowWorkflow:
+		if _, expanded := m.expandedWorkflows[current.workflow]; !expanded {
+			return m, func() tea.Msg { return toggleWorkflowMsg(current.workflow) }
+		}
+	case rowEnvironment, rowExecution, rowOlderExecutions:
+		// Nothing more to show.
+		return m, nil
+	}
+
+	return m.moveCursor(1, false)
+}
+
+// collapse collapses the row under the cursor, or if it cannot be collapsed, moves to its parent.
+func (m Model) collapse() (tea.Model, tea.Cmd) {
+	current, ok := m.currentRow()
+	if !ok {
+		return m, nil
+	}
+
+	switch current.kind {
+	case rowOrganisation:
+		m.collapsedOrgs[current.org] = struct{}{}
+
+		return m, nil
+	case rowEnvironment:
+		return m.moveTo(row{kind: rowOrganisation, org: current.org})
+	case rowWorkflow:
+		if _, expanded := m.expandedWorkflows[current.workflow]; expanded {
+			return m, func() tea.Msg { return toggleWorkflowMsg(current.workflow) }
+		}
+	case rowExecution, rowOlderExecutions:
+		return m.moveTo(row{kind: rowWorkflow, workflow: current.workflow})
+	}
+
+	return m, nil
+}
+
+// pageSize is the number of rows the cursor moves by when paging through the focused tree.
+func (m Model) pageSize() int {
+	if m.focused == viewEnvs {
+		// Leave out the borders and the title.
+		const chrome = 3
+
+		return max(m.topBoxHeight-chrome, 1)
+	}
+
+	return m.workflowTreeHeight()
+}
@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"tkview/internal/tkview"
+
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+const (
+	// uiDetailsBoxWidth is the width of the execution details box, including its borders.
+	uiDetailsBoxWidth = 40
+	// uiDetailsBoxMinTreeWidth is the narrowest the workflow tree can be squeezed to, to make room for the details box.
+	uiDetailsBoxMinTreeWidth = 60
+)
+
+// renderExecutionDetails renders everything known about the passed execution, to sit beside the workflow tree.
+func (m Model) renderExecutionDetails(execution tkview.Execution) string {
+	box := lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), true, true, true, true).
+		Height(m.bottomBoxHeight()).
+		Width(uiDetailsBoxWidth)
+
+	finished := "still running"
+	duration := "still running"
+
+	if !execution.FinishedAt.IsZero() {
+		finished = m.renderTime(execution.FinishedAt)
+		duration = execution.Duration.Round(10 * time.Millisecond).String()
+	}
+
+	lines := []string{
+		"Execution | " + execution.Name,
+		detailLine("Number", fmt.Sprint(execution.Number)),
+		detailLine("Status", renderStatus(execution.Status)+" "+execution.Status),
+		detailLine("Started", m.renderTime(execution.StartedAt)),
+		detailLine("Finished", finished),
+		detailLine("Duration", duration),
+		detailLine("Runner", m.runnerName(execution)),
+		detailLine("Trigger", orUnknown(execution.Trigger)),
+	}
+
+	lines = append(lines, detailMap("Tags", execution.Tags)...)
+	lines = append(lines, detailMap("Config", execution.Config)...)
+
+	return box.Render(strings.Join(lines, "\n"))
+}
+
+// runnerName returns the name of the agent that ran the passed execution, if it is known.
+func (m Model) runnerName(execution tkview.Execution) string {
+	for _, a := range m.agents {
+		if a.ID == execution.RunnerID {
+			return a.Name
+		}
+	}
+
+	return orUnknown(string(execution.RunnerID))
+}
+
+func detailLine(name, value string) string {
+	return fmt.Sprintf("%-10s %s", name, value)
+}
+
+// detailMap renders a titled list of the passed values, sorted by name, or nothing if there are none.
+func detailMap(title string, values map[string]string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	lines := []string{title}
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		lines = append(lines, "  "+detailLine(name, values[name]))
+	}
+
+	return lines
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+
+	return s
+}
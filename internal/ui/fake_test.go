@@ -0,0 +1,127 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"tkview/internal/agent"
+	"tkview/internal/environment"
+	"tkview/internal/organisation"
+	"tkview/internal/workflow"
+)
+
+// testNow is the time of the fixed clock the frames are rendered at.
+var testNow = time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+
+func testClock() time.Time {
+	return testNow
+}
+
+// fakeClient serves a couple of organisations, with everything at fixed times, so that frames can be compared.
+type fakeClient struct {
+	// envErrs fails listing the environments of the organisations within it.
+	envErrs map[organisation.ID]error
+}
+
+func (f *fakeClient) ListOrganisations(context.Context) ([]organisation.Organisation, error) {
+	return []organisation.Organisation{
+		{ID: "acme", Name: "Acme"},
+		{ID: "globex", Name: "Globex"},
+	}, nil
+}
+
+func (f *fakeClient) ListEnvironments(_ context.Context, orgID organisation.ID) ([]environment.Environment, error) {
+	if err := f.envErrs[orgID]; err != nil {
+		return nil, err
+	}
+
+	switch orgID {
+	case "acme":
+		return []environment.Environment{{ID: "prod", Name: "production"}, {ID: "stage", Name: "staging"}}, nil
+	case "globex":
+		return []environment.Environment{{ID: "dev", Name: "development"}}, nil
+	default:
+		return nil, fmt.Errorf("organisation %q: %w", orgID, errTestNotFound)
+	}
+}
+
+func (f *fakeClient) ListAgents(_ context.Context, orgID organisation.ID) ([]agent.Agent, error) {
+	return []agent.Agent{
+		{ID: agent.ID(orgID + "-runner"), Name: string(orgID) + "-runner", Type: "runner", Version: "2.1.150", LastSeen: testNow.Add(-time.Minute)},
+	}, nil
+}
+
+func (f *fakeClient) ListWorkflows(context.Context, organisation.ID, environment.ID) ([]workflow.Workflow, error) {
+	return []workflow.Workflow{
+		{ID: "smoke", Name: "smoke", LastExecutionAt: testNow.Add(-10 * time.Minute), LastExecutionStatus: "passed"},
+		{ID: "api-tests", Name: "api-tests", LastExecutionAt: testNow.Add(-time.Hour), LastExecutionStatus: "failed", Labels: map[string]string{"team": "qa"}},
+		{ID: "nightly-cleanup", Name: "nightly-cleanup"},
+	}, nil
+}
+
+func (f *fakeClient) ListExecutions(
+	_ context.Context, orgID organisation.ID, _ environment.ID, id workflow.ID, page workflow.Page,
+) ([]workflow.Execution, error) {
+	if id == "nightly-cleanup" || page.Number > 0 {
+		return nil, nil
+	}
+
+	statuses := []string{"passed", "failed", "passed"}
+
+	ee := make([]workflow.Execution, 0, len(statuses))
+	for i, status := range statuses {
+		n := len(statuses) - i
+		started := testNow.Add(-time.Duration(i+1) * 10 * time.Minute)
+
+		ee = append(ee, workflow.Execution{
+			ID:         workflow.ExecutionID(fmt.Sprintf("%s-%d", id, n)),
+			Name:       fmt.Sprintf("%s-%d", id, n),
+			Number:     n,
+			StartedAt:  started,
+			FinishedAt: started.Add(90 * time.Second),
+			Duration:   90 * time.Second,
+			Status:     status,
+			RunnerID:   agent.ID(orgID + "-runner"),
+			Trigger:    "cron",
+			Config:     map[string]string{"url": "https://example.com"},
+		})
+	}
+
+	return ee, nil
+}
+
+func (f *fakeClient) ListSteps(context.Context, organisation.ID, environment.ID, workflow.ExecutionID) ([]workflow.Step, error) {
+	return []workflow.Step{
+		{ID: "rcheckout", Name: "checkout", Status: "passed", StartedAt: testNow.Add(-20 * time.Minute), Duration: 5 * time.Second},
+		{ID: "rtests", Name: "tests", Status: "passed", StartedAt: testNow.Add(-19 * time.Minute), Duration: time.Minute},
+	}, nil
+}
+
+func (f *fakeClient) GetMetrics(context.Context, organisation.ID, environment.ID, workflow.ID) (workflow.Metrics, error) {
+	return workflow.Metrics{Executions: 3, Failed: 1, AverageDuration: 90 * time.Second}, nil
+}
+
+func (f *fakeClient) StartExecution(_ context.Context, _ organisation.ID, _ environment.ID, id workflow.ID) (workflow.Execution, error) {
+	return workflow.Execution{ID: workflow.ExecutionID(id + "-4"), Name: string(id) + "-4", Number: 4, StartedAt: testNow, Status: "running"}, nil
+}
+
+func (f *fakeClient) AbortExecution(context.Context, organisation.ID, environment.ID, workflow.ExecutionID) error {
+	return nil
+}
+
+func (f *fakeClient) StreamLogs(ctx context.Context, _ organisation.ID, _ environment.ID, _ workflow.ExecutionID, lines chan<- workflow.LogLine) error {
+	for _, l := range []workflow.LogLine{
+		{StepID: "rcheckout", Text: "Cloning into '/data/repo'..."},
+		{StepID: "rtests", Text: "Running 12 tests"},
+		{StepID: "rtests", Text: "12 passed"},
+	} {
+		select {
+		case lines <- l:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"tkview/internal/environment"
+	"tkview/internal/tkview"
+
+	"github.com/charmbracelet/bubbles/v2/key"
+	"github.com/charmbracelet/bubbles/v2/textinput"
+	"github.com/charmbracelet/bubbletea/v2"
+)
+
+// filter narrows down a tree to the rows that fuzzy match its query, as it is typed.
+type filter struct {
+	input   textinput.Model
+	editing bool
+}
+
+func newFilter() filter {
+	input := textinput.New()
+	input.Prompt = "/"
+	input.Placeholder = "filter"
+
+	return filter{input: input}
+}
+
+func (f filter) query() string {
+	return strings.TrimSpace(f.input.Value())
+}
+
+// active reports whether the filter is narrowing down its tree.
+func (f filter) active() bool {
+	return f.query() != ""
+}
+
+// cleared returns the filter with its query removed, no longer being typed into.
+func (f filter) cleared() filter {
+	f.editing = false
+	f.input.Reset()
+	f.input.Blur()
+
+	return f
+}
+
+// shown reports whether the filter should be shown in the title of its tree.
+func (f filter) shown() bool {
+	return f.editing || f.active()
+}
+
+// matches reports whether any of the passed values fuzzy match the query, an empty query matches everything.
+func (f filter) matches(values ...string) bool {
+	q := f.query()
+	if q == "" {
+		return true
+	}
+
+	return slices.ContainsFunc(values, func(v string) bool {
+		return fuzzyMatch(q, v)
+	})
+}
+
+// fuzzyMatch reports whether the characters of the query appear in s, in order, ignoring case.
+// So "apit" matches "api-tests".
+func fuzzyMatch(query, s string) bool {
+	s = strings.ToLower(s)
+
+	for _, r := range strings.ToLower(query) {
+		i := strings.IndexRune(s, r)
+		if i < 0 {
+			return false
+		}
+
+		s = s[i+utf8.RuneLen(r):]
+	}
+
+	return true
+}
+
+// filterOf returns the filter of the passed view, if it has one.
+func (m Model) filterOf(v view) (filter, bool) {
+	switch v {
+	case viewEnvs:
+		return m.envFilter, true
+	case viewWorkflows:
+		return m.workflowFilter, true
+	case viewAgents:
+	}
+
+	return filter{}, false
+}
+
+// withFilter returns a copy of the Model with the filter of the passed view replaced.
+func (m Model) withFilter(v view, f filter) Model {
+	switch v {
+	case viewEnvs:
+		m.envFilter = f
+	case viewWorkflows:
+		m.workflowFilter = f
+	case viewAgents:
+	}
+
+	return m
+}
+
+// editingFilter reports whether the filter of the focused tree is being typed into.
+func (m Model) editingFilter() bool {
+	f, ok := m.filterOf(m.focused)
+
+	return ok && f.editing
+}
+
+// startFilter starts typing into the filter of the focused tree, carrying on from any previous query.
+func (m Model) startFilter() (tea.Model, tea.Cmd) {
+	f, ok := m.filterOf(m.focused)
+	if !ok {
+		return m, nil
+	}
+
+	f.editing = true
+	cmd := f.input.Focus()
+
+	return m.withFilter(m.focused, f), cmd
+}
+
+// clearFilter stops the focused tree from being filtered.
+func (m Model) clearFilter() (tea.Model, tea.Cmd) {
+	f, ok := m.filterOf(m.focused)
+	if !ok {
+		return m, nil
+	}
+
+	return m.withFilter(m.focused, f.cleared()), nil
+}
+
+// updateFilter handles key presses whilst typing into a filter.
+// The cursor can still be moved up and down, through the rows that match so far.
+func (m Model) updateFilter(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	f, _ := m.filterOf(m.focused)
+
+	switch {
+	case key.Matches(msg.Key(), m.keyMap.Close):
+		return m.clearFilter()
+	case key.Matches(msg.Key(), m.keyMap.Select):
+		f.editing = false
+		f.input.Blur()
+	case key.Matches(msg.Key(), m.keyMap.Next):
+		return m.moveCursor(1, true)
+	case key.Matches(msg.Key(), m.keyMap.Prev):
+		return m.moveCursor(-1, true)
+	default:
+		var cmd tea.Cmd
+		f.input, cmd = f.input.Update(msg)
+
+		return m.withFilter(m.focused, f), cmd
+	}
+
+	return m.withFilter(m.focused, f), nil
+}
+
+// visibleOrgs returns the organisations, and their environments, that match the environment filter.
+// All the environments of a matching organisation are kept, otherwise only those that match themselves.
+func (m Model) visibleOrgs() []tkview.Organisation {
+	if !m.envFilter.active() {
+		return m.orgs
+	}
+
+	var orgs []tkview.Organisation
+
+	for _, org := range m.orgs {
+		if m.envFilter.matches(org.Name) {
+			orgs = append(orgs, org)
+			continue
+		}
+
+		envs := slices.DeleteFunc(slices.Clone(org.Envs), func(e environment.Environment) bool {
+			return !m.envFilter.matches(e.Name)
+		})
+
+		if len(envs) > 0 {
+			org.Envs = envs
+			orgs = append(orgs, org)
+		}
+	}
+
+	return orgs
+}
+
+// visibleWorkflows returns the workflows, and their executions, that match the workflow filter.
+// Workflows are matched by name, last status, or any label, and executions by name or status.
+// All the executions of a matching workflow are kept, otherwise only those that match themselves.
+func (m Model) visibleWorkflows() []tkview.Workflow {
+	if !m.workflowFilter.active() {
+		return m.workflows
+	}
+
+	var workflows []tkview.Workflow
+
+	for _, wf := range m.workflows {
+		values := []string{wf.Name, wf.LastExecutionStatus}
+		for k, v := range wf.Labels {
+			values = append(values, k+"="+v)
+		}
+
+		if m.workflowFilter.matches(values...) {
+			workflows = append(workflows, wf)
+			continue
+		}
+
+		executions := slices.DeleteFunc(slices.Clone(wf.Executions), func(e tkview.Execution) bool {
+			return !m.workflowFilter.matches(e.Name, e.Status)
+		})
+
+		if len(executions) > 0 {
+			wf.Executions = executions
+			// Older executions are selected as soon as they load, which may be before one that matches.
+			wf.MoreExecutions = false
+			workflows = append(workflows, wf)
+		}
+	}
+
+	return workflows
+}
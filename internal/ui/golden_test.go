@@ -0,0 +1,244 @@
+package ui
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"tkview/internal/organisation"
+	"tkview/internal/tkview"
+
+	"github.com/charmbracelet/bubbletea/v2"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata, rather than comparing against them")
+
+var errTestNotFound = errors.New("not found")
+
+// goldenSizes are the terminal sizes every script is rendered at.
+var goldenSizes = []tea.WindowSizeMsg{
+	{Width: 80, Height: 24},
+	{Width: 120, Height: 40},
+	{Width: 160, Height: 50},
+}
+
+// script is a sequence of messages to drive a Model through, before comparing the frame it renders.
+type script struct {
+	name   string
+	client *fakeClient
+	msgs   []tea.Msg
+}
+
+func TestGolden(t *testing.T) {
+	scripts := []script{
+		{name: "startup", client: &fakeClient{}},
+		{name: "expand-workflow", client: &fakeClient{}, msgs: keys("W", "right")},
+		{name: "select-execution", client: &fakeClient{}, msgs: keys("W", "right", "down")},
+		{name: "logs", client: &fakeClient{}, msgs: keys("W", "right", "down", "l")},
+		{name: "filter-workflows", client: &fakeClient{}, msgs: keys("W", "/", "a", "p", "i")},
+		{name: "filter-executions", client: &fakeClient{}, msgs: keys("W", "right", "/", "f", "a", "i", "l")},
+		{name: "focus-environments", client: &fakeClient{}, msgs: keys("E", "down", "down")},
+		{
+			name: "organisation-failed",
+			client: &fakeClient{envErrs: map[organisation.ID]error{
+				"globex": errors.New("list environments: connection refused"),
+			}},
+		},
+		{
+			name: "all-organisations-failed",
+			client: &fakeClient{envErrs: map[organisation.ID]error{
+				"acme":   errors.New("list environments: connection refused"),
+				"globex": errors.New("list environments: connection refused"),
+			}},
+		},
+	}
+
+	for _, s := range scripts {
+		for _, size := range goldenSizes {
+			name := fmt.Sprintf("%s-%dx%d", s.name, size.Width, size.Height)
+
+			t.Run(name, func(t *testing.T) {
+				m := newTestModel(s.client)
+
+				m = settle(t, m, size, m.Init())
+
+				for _, msg := range s.msgs {
+					m = settle(t, m, msg, nil)
+				}
+
+				checkGolden(t, name, m.View())
+			})
+		}
+	}
+}
+
+// newTestModel returns a Model of the passed client, rendering at a fixed time, and without a blinking cursor.
+func newTestModel(c *fakeClient) Model {
+	m := NewModel(tkview.New(c), 0).WithClock(testClock)
+
+	for _, f := range []*filter{&m.envFilter, &m.workflowFilter} {
+		f.input.Styles.Cursor.Blink = false
+		// The style only takes effect once updated.
+		f.input, _ = f.input.Update(nil)
+	}
+
+	return m
+}
+
+// settleTimeout is how long the commands of a single update may take, the fake client responds straight away.
+const settleTimeout = 5 * time.Second
+
+// settle updates the model with the passed message, and everything its command leads to,
+// until there is nothing left to do, as if a person waited for each key press to take effect.
+// The passed command is run first.
+func settle(t *testing.T, m Model, msg tea.Msg, cmd tea.Cmd) Model {
+	t.Helper()
+
+	queue := append(run(t, cmd), msg)
+
+	// Anything that keeps on sending messages would never settle.
+	const maxMsgs = 1000
+
+	for i := 0; len(queue) > 0; i++ {
+		if i == maxMsgs {
+			t.Fatalf("model did not settle after %d messages", maxMsgs)
+		}
+
+		msg := queue[0]
+		queue = queue[1:]
+
+		next, cmd := m.Update(msg)
+
+		var ok bool
+		if m, ok = next.(Model); !ok {
+			t.Fatalf("Update() returned %T, want a Model", next)
+		}
+
+		queue = append(queue, run(t, cmd)...)
+	}
+
+	return m
+}
+
+// run runs the passed command, and any commands it batches, concurrently, as tea would.
+// The messages are returned in the order the commands were batched, so that the frames are reproducible.
+// The cursor blinking is left out, as it would never settle.
+func run(t *testing.T, cmd tea.Cmd) []tea.Msg {
+	t.Helper()
+
+	if cmd == nil {
+		return nil
+	}
+
+	done := make(chan tea.Msg, 1)
+
+	go func() { done <- cmd() }()
+
+	var msg tea.Msg
+
+	select {
+	case msg = <-done:
+	case <-time.After(settleTimeout):
+		// Batched commands are run on other goroutines, so cannot stop the test.
+		t.Errorf("command did not finish within %s", settleTimeout)
+
+		return nil
+	}
+
+	switch msg := msg.(type) {
+	case nil:
+		return nil
+	case tea.BatchMsg:
+		var (
+			wg   sync.WaitGroup
+			msgs = make([][]tea.Msg, len(msg))
+		)
+
+		for i, cmd := range msg {
+			wg.Go(func() { msgs[i] = run(t, cmd) })
+		}
+
+		wg.Wait()
+
+		var ret []tea.Msg
+		for _, m := range msgs {
+			ret = append(ret, m...)
+		}
+
+		return ret
+	}
+
+	if strings.HasSuffix(reflect.TypeOf(msg).PkgPath(), "/cursor") {
+		return nil
+	}
+
+	return []tea.Msg{msg}
+}
+
+// keys returns the key presses of the passed keys, named as in the key map.
+func keys(names ...string) []tea.Msg {
+	special := map[string]rune{
+		"down":  tea.KeyDown,
+		"up":    tea.KeyUp,
+		"left":  tea.KeyLeft,
+		"right": tea.KeyRight,
+		"enter": tea.KeyEnter,
+		"esc":   tea.KeyEscape,
+	}
+
+	msgs := make([]tea.Msg, 0, len(names))
+
+	for _, name := range names {
+		if code, ok := special[name]; ok {
+			msgs = append(msgs, tea.KeyPressMsg{Code: code})
+
+			continue
+		}
+
+		r := []rune(name)[0]
+		if lower := []rune(strings.ToLower(name))[0]; lower != r {
+			msgs = append(msgs, tea.KeyPressMsg{Code: lower, Text: name, Mod: tea.ModShift})
+
+			continue
+		}
+
+		msgs = append(msgs, tea.KeyPressMsg{Code: r, Text: name})
+	}
+
+	return msgs
+}
+
+// checkGolden compares the passed frame against the golden file of the passed name, or updates it with -update.
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+
+	if *update {
+		if err := os.MkdirAll("testdata", 0o750); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(got), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		return
+	}
+
+	want, err := os.ReadFile(path) //nolint:gosec // The path is of a golden file within testdata.
+	if err != nil {
+		t.Fatalf("read golden file, run with -update to create it: %v", err)
+	}
+
+	if got != string(want) {
+		t.Errorf("frame differs from %s, run with -update to accept it\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
@@ -0,0 +1,17 @@
+package ui
+
+import "tkview/internal/testkube"
+
+// errorHint explains the passed error, and what can be done about it, if it is a failure the user can act on.
+func errorHint(err error) string {
+	switch {
+	case testkube.IsUnauthorized(err):
+		return "The API token was rejected, it may have expired. Provide a new token, or switch profile with (P)."
+	case testkube.IsNotFound(err):
+		return "It no longer exists, it may have been deleted. Press (r) to refresh."
+	case testkube.IsAgentUnavailable(err):
+		return "The agent is not responding, check that it is running. Refreshes will keep trying."
+	default:
+		return ""
+	}
+}
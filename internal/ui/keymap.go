@@ -8,7 +8,28 @@ type keyMap struct {
 	Quit              key.Binding
 	Next              key.Binding
 	Prev              key.Binding
+	Expand            key.Binding
+	Collapse          key.Binding
+	First             key.Binding
+	Last              key.Binding
+	PageDown          key.Binding
+	PageUp            key.Binding
 	Select            key.Binding
+	Filter            key.Binding
+	Sort              key.Binding
+	Start             key.Binding
+	Abort             key.Binding
+	Confirm           key.Binding
+	Logs              key.Binding
+	Close             key.Binding
+	Follow            key.Binding
+	NextStep          key.Binding
+	PrevStep          key.Binding
+	OlderError        key.Binding
+	NewerError        key.Binding
+	ClearErrors       key.Binding
+	Refresh           key.Binding
+	Profiles          key.Binding
 	FocusEnvironments key.Binding
 	FocusAgents       key.Binding
 	FocusWorkflows    key.Binding
@@ -19,7 +40,28 @@ func defaultKeyMap() keyMap {
 		Quit:              key.NewBinding(key.WithKeys("ctrl+c")),
 		Next:              key.NewBinding(key.WithKeys("down")),
 		Prev:              key.NewBinding(key.WithKeys("up")),
+		Expand:            key.NewBinding(key.WithKeys("right")),
+		Collapse:          key.NewBinding(key.WithKeys("left")),
+		First:             key.NewBinding(key.WithKeys("home")),
+		Last:              key.NewBinding(key.WithKeys("end")),
+		PageDown:          key.NewBinding(key.WithKeys("pgdown")),
+		PageUp:            key.NewBinding(key.WithKeys("pgup")),
 		Select:            key.NewBinding(key.WithKeys("enter")),
+		Filter:            key.NewBinding(key.WithKeys("/")),
+		Sort:              key.NewBinding(key.WithKeys("o")),
+		Start:             key.NewBinding(key.WithKeys("s")),
+		Abort:             key.NewBinding(key.WithKeys("x")),
+		Confirm:           key.NewBinding(key.WithKeys("y")),
+		Logs:              key.NewBinding(key.WithKeys("l")),
+		Close:             key.NewBinding(key.WithKeys("esc")),
+		Follow:            key.NewBinding(key.WithKeys("f")),
+		NextStep:          key.NewBinding(key.WithKeys("]")),
+		PrevStep:          key.NewBinding(key.WithKeys("[")),
+		OlderError:        key.NewBinding(key.WithKeys("<")),
+		NewerError:        key.NewBinding(key.WithKeys(">")),
+		ClearErrors:       key.NewBinding(key.WithKeys("shift+c")),
+		Refresh:           key.NewBinding(key.WithKeys("r")),
+		Profiles:          key.NewBinding(key.WithKeys("shift+p")),
 		FocusEnvironments: key.NewBinding(key.WithKeys("shift+e")),
 		FocusAgents:       key.NewBinding(key.WithKeys("shift+a")),
 		FocusWorkflows:    key.NewBinding(key.WithKeys("shift+w")),
@@ -0,0 +1,239 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"tkview/internal/tkview"
+	"tkview/internal/workflow"
+
+	"github.com/charmbracelet/bubbles/v2/viewport"
+	"github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+const (
+	// The number of log lines that can be waiting to be displayed before the stream blocks.
+	logBufferSize = 1024
+	// The most log lines that will be added to the view at once.
+	logBatchSize = 256
+	// 2 for the top and bottom borders.
+	// 1 for the title line.
+	uiLogBoxBorderHeight = 3
+	// 2 for the left and right borders.
+	uiLogBoxBorderWidth = 2
+)
+
+// logView holds the state of the log viewer for a single execution.
+// The default logView is closed.
+type logView struct {
+	execution tkview.Execution
+	stepNames map[workflow.StepID]string
+	lastStep  workflow.StepID
+	content   []string
+	// boundaries are the indexes of content lines that mark the start of a step.
+	boundaries []int
+	viewport   viewport.Model
+	follow     bool
+	cancel     context.CancelFunc
+}
+
+func newLogView(execution tkview.Execution, cancel context.CancelFunc, width, height int) logView {
+	stepNames := make(map[workflow.StepID]string)
+	addStepNames(stepNames, execution.Steps)
+
+	return logView{
+		execution: execution,
+		stepNames: stepNames,
+		viewport: viewport.New(
+			viewport.WithWidth(width-uiLogBoxBorderWidth),
+			viewport.WithHeight(height-uiLogBoxBorderHeight),
+		),
+		follow: true,
+		cancel: cancel,
+	}
+}
+
+func addStepNames(names map[workflow.StepID]string, steps []workflow.Step) {
+	for _, step := range steps {
+		names[step.ID] = step.Name
+		addStepNames(names, step.Steps)
+	}
+}
+
+func (l logView) isOpen() bool {
+	return l.execution.ID != ""
+}
+
+func (l logView) close() logView {
+	if l.cancel != nil {
+		l.cancel()
+	}
+
+	return logView{}
+}
+
+func (l logView) setSize(width, height int) logView {
+	l.viewport.SetWidth(width - uiLogBoxBorderWidth)
+	l.viewport.SetHeight(height - uiLogBoxBorderHeight)
+
+	return l
+}
+
+func (l logView) addLines(lines []workflow.LogLine) logView {
+	for _, line := range lines {
+		if line.StepID != "" && line.StepID != l.lastStep {
+			name, ok := l.stepNames[line.StepID]
+			if !ok {
+				name = string(line.StepID)
+			}
+
+			l.boundaries = append(l.boundaries, len(l.content))
+			l.content = append(l.content, fmt.Sprintf("── %s ──", name))
+			l.lastStep = line.StepID
+		}
+
+		l.content = append(l.content, line.Text)
+	}
+
+	l.viewport.SetContentLines(l.content)
+
+	if l.follow {
+		l.viewport.GotoBottom()
+	}
+
+	return l
+}
+
+func (l logView) toggleFollow() logView {
+	l.follow = !l.follow
+
+	if l.follow {
+		l.viewport.GotoBottom()
+	}
+
+	return l
+}
+
+func (l logView) nextStep() logView {
+	for _, b := range l.boundaries {
+		if b > l.viewport.YOffset {
+			l.viewport.SetYOffset(b)
+			l.follow = false
+
+			break
+		}
+	}
+
+	return l
+}
+
+func (l logView) prevStep() logView {
+	for i := len(l.boundaries) - 1; i >= 0; i-- {
+		if l.boundaries[i] < l.viewport.YOffset {
+			l.viewport.SetYOffset(l.boundaries[i])
+			l.follow = false
+
+			break
+		}
+	}
+
+	return l
+}
+
+func (l logView) scroll(msg tea.Msg) logView {
+	offset := l.viewport.YOffset
+	l.viewport, _ = l.viewport.Update(msg)
+
+	// Scrolling away from the bottom stops following, and scrolling back to it resumes.
+	if l.viewport.YOffset != offset {
+		l.follow = l.viewport.AtBottom()
+	}
+
+	return l
+}
+
+type logLinesMsg struct {
+	id    workflow.ExecutionID
+	lines []workflow.LogLine
+	more  <-chan workflow.LogLine
+}
+
+type logsDoneMsg workflow.ExecutionID
+
+func (m Model) streamLogs(ctx context.Context, id workflow.ExecutionID, lines chan<- workflow.LogLine) tea.Cmd {
+	return func() tea.Msg {
+		defer close(lines)
+
+		err := m.tkview.StreamExecutionLogs(ctx, id, lines)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return errMsg(fmt.Errorf("stream execution logs: %w", err))
+		}
+
+		return nil
+	}
+}
+
+func waitForLogs(id workflow.ExecutionID, lines <-chan workflow.LogLine) tea.Cmd {
+	return func() tea.Msg {
+		line, ok := <-lines
+		if !ok {
+			return logsDoneMsg(id)
+		}
+
+		batch := []workflow.LogLine{line}
+
+		// Gather up any other waiting lines to avoid rendering once for every line.
+		for len(batch) < logBatchSize {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					// The next wait will report that the stream is done.
+					return logLinesMsg{id: id, lines: batch, more: lines}
+				}
+
+				batch = append(batch, line)
+			default:
+				return logLinesMsg{id: id, lines: batch, more: lines}
+			}
+		}
+
+		return logLinesMsg{id: id, lines: batch, more: lines}
+	}
+}
+
+func (m Model) openLogs() (tea.Model, tea.Cmd) {
+	currentExecution, err := m.tkview.GetCurrentExecution()
+	if err != nil {
+		// Nothing to show without a selected execution.
+		return m, nil
+	}
+
+	m.logs = m.logs.close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lines := make(chan workflow.LogLine, logBufferSize)
+	m.logs = newLogView(currentExecution, cancel, m.width, m.bottomBoxHeight())
+
+	return m, tea.Batch(
+		m.streamLogs(ctx, currentExecution.ID, lines),
+		waitForLogs(currentExecution.ID, lines),
+	)
+}
+
+func (m Model) renderLogs() string {
+	box := lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder(), true, true, true, true).
+		Height(m.bottomBoxHeight()).
+		Width(m.width)
+
+	follow := "off"
+	if m.logs.follow {
+		follow = "on"
+	}
+
+	title := fmt.Sprintf("Logs %s | (f)ollow: %s | ([/]) step | (esc) close", m.logs.execution.Name, follow)
+
+	return box.Render(lipgloss.JoinVertical(0, title, m.logs.viewport.View()))
+}
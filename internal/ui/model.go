@@ -1,7 +1,12 @@
 package ui
 
 import (
+	"context"
+	"time"
+
 	"tkview/internal/agent"
+	"tkview/internal/organisation"
+	"tkview/internal/state"
 	"tkview/internal/tkview"
 	"tkview/internal/workflow"
 
@@ -23,6 +28,30 @@ const (
 	// 3 for the top, header, and bottom, borders.
 	// 1 for the header line.
 	uiTableBorderHeight = 4
+	// 2 for the top and bottom borders.
+	// 1 for the title line.
+	// 3 for the hint, if any, and the error chain.
+	uiErrorBoxHeight = 6
+	// The number of recent errors kept for display.
+	uiMaxErrorHistory = 20
+)
+
+// load identifies a kind of data load, so that an in flight load can be
+// cancelled when the user moves on and it is superseded by another.
+type load int
+
+const (
+	loadEnv load = iota
+	loadWorkflow
+	loadExecution
+	loadRefresh
+	loadOlderExecutions
+	loadMetrics
+	loadRevalidation
+	loadWorkflowTree
+	// loadProfile lasts as long as the profile, for starting and aborting executions,
+	// which nothing supersedes, but are of no use once switched to another profile.
+	loadProfile
 )
 
 // Model defines our Elm Architecture model for use in a tea program.
@@ -31,29 +60,78 @@ type Model struct {
 	topBoxCount       int
 	topBoxHeight      int
 	tableBorderHeight int
+	errorBoxHeight    int
 	keyMap            keyMap
 	tkview            *tkview.TKView
 	focused           view
 	orgs              []tkview.Organisation
+	orgsLoaded        bool
 	agents            []agent.Agent
 	workflows         []tkview.Workflow
 	expandedWorkflows map[workflow.ID]struct{}
+	workflowOffset    int
+	envFilter         filter
+	workflowFilter    filter
+	sortMode          sortMode
+	sessions          SessionStore
+	sessionKey        string
+	restore           state.Session
+	collapsedOrgs     map[organisation.ID]struct{}
+	orgCursor         organisation.ID
+	confirmAbort      workflow.ExecutionID
+	logs              logView
+	errs              []error
+	errIndex          int
+	refreshInterval   time.Duration
+	lastRefresh       time.Time
+	workflowsLoaded   bool
+	revalidating      bool
+	agentTimedOut     bool
+	loads             map[load]context.CancelFunc
+	profileLoad       context.Context //nolint:containedctx // Cancelled by switching profile, see loadProfile.
+	profiles          []Profile
+	currentProfile    string
+	picker            profilePicker
+	defaultOrg        string
+	defaultEnv        string
+	now               func() time.Time
 }
 
 // NewModel creates a new Model.
 // It will not be initialised and so Init should be called
 // before first use to ensure that everything operates as expected.
-func NewModel(tkview *tkview.TKView) Model {
-	return Model{
+// Agents, workflows, and expanded executions are refreshed every refreshInterval,
+// unless it is zero, in which case they are only refreshed on request.
+func NewModel(tkview *tkview.TKView, refreshInterval time.Duration) Model {
+	m := Model{
 		width:             0,
 		height:            0,
 		topBoxCount:       uiTopBoxCount,
 		topBoxHeight:      uiTopBoxMaxHeight,
 		tableBorderHeight: uiTableBorderHeight,
+		errorBoxHeight:    uiErrorBoxHeight,
 		keyMap:            defaultKeyMap(),
 		tkview:            tkview,
 		expandedWorkflows: make(map[workflow.ID]struct{}),
+		collapsedOrgs:     make(map[organisation.ID]struct{}),
+		envFilter:         newFilter(),
+		workflowFilter:    newFilter(),
+		refreshInterval:   refreshInterval,
+		loads:             make(map[load]context.CancelFunc),
+		now:               time.Now,
 	}
+
+	m.profileLoad = m.startLoad(loadProfile)
+
+	return m
+}
+
+// WithClock returns a copy of the Model that uses the passed function to tell the time,
+// rather than time.Now. A fixed clock makes rendering reproducible, such as for comparing frames.
+func (m Model) WithClock(now func() time.Time) Model {
+	m.now = now
+
+	return m
 }
 
 // Init initialises the model so that it is ready for use.
@@ -61,5 +139,23 @@ func (m Model) Init() tea.Cmd {
 	return tea.Batch(
 		textinput.Blink,
 		m.getOrgTree,
+		m.tick(),
+		m.waitForRevalidation(m.startLoad(loadRevalidation)),
 	)
 }
+
+// startLoad cancels any in flight load of the passed kind, along with any other passed kinds
+// that are superseded by it, and returns the context to use for the new load.
+func (m Model) startLoad(l load, superseded ...load) context.Context {
+	for _, k := range append([]load{l}, superseded...) {
+		if cancel, ok := m.loads[k]; ok {
+			cancel()
+			delete(m.loads, k)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.loads[l] = cancel
+
+	return ctx
+}
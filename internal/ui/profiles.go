@@ -0,0 +1,178 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"tkview/internal/organisation"
+	"tkview/internal/state"
+	"tkview/internal/tkview"
+	"tkview/internal/workflow"
+
+	"github.com/charmbracelet/bubbles/v2/key"
+	"github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// Profile is a named connection to a testkube instance that can be switched to from within the UI.
+type Profile struct {
+	Name string
+	// Connect creates the TKView for the profile, it is only called when the profile is switched to.
+	Connect func() (*tkview.TKView, error)
+	// RefreshInterval is used as the Model refreshInterval whilst the profile is in use.
+	RefreshInterval time.Duration
+	// Organisation and Environment are the names, or IDs, of the environment to select on connection.
+	Organisation string
+	Environment  string
+	// SessionKey is where the profile's session is kept, see WithSessions.
+	SessionKey string
+}
+
+// WithProfiles returns a copy of the Model that can switch between the passed profiles.
+// The named current profile should be the one that the Model was created with, so that
+// its default organisation and environment are selected on start.
+func (m Model) WithProfiles(current string, profiles []Profile) Model {
+	m.profiles = profiles
+	m.currentProfile = current
+
+	for _, p := range profiles {
+		if p.Name == current {
+			m.defaultOrg = p.Organisation
+			m.defaultEnv = p.Environment
+		}
+	}
+
+	return m
+}
+
+// profilePicker holds the state of the profile switcher.
+// The default profilePicker is closed.
+type profilePicker struct {
+	open   bool
+	cursor int
+}
+
+func (m Model) openProfilePicker() (tea.Model, tea.Cmd) {
+	m.picker = profilePicker{open: true}
+
+	for i, p := range m.profiles {
+		if p.Name == m.currentProfile {
+			m.picker.cursor = i
+		}
+	}
+
+	return m, nil
+}
+
+func (m Model) updateProfilePicker(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg.Key(), m.keyMap.Close):
+		m.picker = profilePicker{}
+	case key.Matches(msg.Key(), m.keyMap.Next):
+		m.picker.cursor = (m.picker.cursor + 1) % len(m.profiles)
+	case key.Matches(msg.Key(), m.keyMap.Prev):
+		m.picker.cursor = (m.picker.cursor - 1 + len(m.profiles)) % len(m.profiles)
+	case key.Matches(msg.Key(), m.keyMap.Select):
+		p := m.profiles[m.picker.cursor]
+		m.picker = profilePicker{}
+
+		return m.switchProfile(p)
+	}
+
+	return m, nil
+}
+
+// switchProfile connects to the passed profile and reloads everything from scratch.
+func (m Model) switchProfile(p Profile) (tea.Model, tea.Cmd) {
+	tk, err := p.Connect()
+	if err != nil {
+		return m, errCmd(fmt.Errorf("connect to profile %q: %w", p.Name, err))
+	}
+
+	// Nothing still loading from the previous profile is of any use.
+	for l, cancel := range m.loads {
+		cancel()
+		delete(m.loads, l)
+	}
+
+	m.logs = m.logs.close()
+
+	var cmds []tea.Cmd
+
+	// Remember where the previous profile was up to, before everything is reset.
+	if err := m.SaveSession(); err != nil {
+		cmds = append(cmds, errCmd(err))
+	}
+
+	// Only start ticking if the previous profile was not, otherwise there would be two tickers.
+	startTicking := m.refreshInterval <= 0 && p.RefreshInterval > 0
+
+	m.tkview = tk
+	m.profileLoad = m.startLoad(loadProfile)
+	m.currentProfile = p.Name
+	m.refreshInterval = p.RefreshInterval
+	m.defaultOrg = p.Organisation
+	m.defaultEnv = p.Environment
+	m.orgs = nil
+	m.orgsLoaded = false
+	m.agents = nil
+	m.workflows = nil
+	m.workflowsLoaded = false
+	m.revalidating = false
+	m.expandedWorkflows = make(map[workflow.ID]struct{})
+	m.collapsedOrgs = make(map[organisation.ID]struct{})
+	m.envFilter = m.envFilter.cleared()
+	m.workflowFilter = m.workflowFilter.cleared()
+	m.workflowOffset = 0
+	m.orgCursor = ""
+	m.confirmAbort = ""
+	m.agentTimedOut = false
+	m.restore = state.Session{}
+
+	if m.sessions != nil {
+		m.sessionKey = p.SessionKey
+
+		session, err := m.sessions.Load(p.SessionKey)
+		if err != nil {
+			cmds = append(cmds, errCmd(fmt.Errorf("load session: %w", err)))
+		} else {
+			m = m.restoreSession(session)
+		}
+	}
+
+	cmds = append(cmds, m.getOrgTree, m.waitForRevalidation(m.startLoad(loadRevalidation)))
+
+	if startTicking {
+		cmds = append(cmds, m.tick())
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+func (m Model) renderProfilePicker() string {
+	box := lipgloss.NewStyle().
+		Border(lipgloss.DoubleBorder(), true, true, true, true).
+		Height(m.bottomBoxHeight()).
+		Width(m.width)
+
+	lines := []string{"(P)rofiles | (enter) switch | (esc) close"}
+
+	for i, p := range m.profiles {
+		line := "  " + p.Name
+		if p.Name == m.currentProfile {
+			line = "* " + p.Name
+		}
+
+		if i == m.picker.cursor {
+			line = lipgloss.NewStyle().
+				Background(lipgloss.BrightBlue).
+				Foreground(lipgloss.White).
+				Render(line)
+		}
+
+		lines = append(lines, line)
+	}
+
+	return box.Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,258 @@
+package ui
+
+import (
+	"strings"
+
+	"tkview/internal/tkview"
+	"tkview/internal/workflow"
+
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/charmbracelet/lipgloss/v2/tree"
+)
+
+// The branches drawn by lipgloss's default tree enumerator and indenter, with its padding.
+const (
+	treeBranch     = "├── "
+	treeLastBranch = "└── "
+	treeIndent     = "│   "
+	treeLastIndent = "    "
+)
+
+// workflowSpan is a row of the workflow tree, along with where it is drawn.
+// Working out the spans is cheap, so that only the rows in view need to be rendered.
+type workflowSpan struct {
+	row row
+	// first is the line of the tree that the row starts on, and height is the number of lines it takes up, including its steps.
+	first, height int
+	// last is set for the last child of its parent, and lastParent for the children of the last workflow.
+	last, lastParent bool
+	workflow         tkview.Workflow
+	execution        tkview.Execution
+}
+
+// workflowSpans returns every row of the workflow tree, in the order they are displayed.
+func (m Model) workflowSpans() []workflowSpan {
+	var (
+		spans []workflowSpan
+		line  int
+	)
+
+	add := func(s workflowSpan) {
+		s.first = line
+		line += s.height
+		spans = append(spans, s)
+	}
+
+	workflows := m.visibleWorkflows()
+
+	for i, wf := range workflows {
+		lastWorkflow := i == len(workflows)-1
+
+		add(workflowSpan{row: row{kind: rowWorkflow, workflow: wf.ID}, height: 1, last: lastWorkflow, workflow: wf})
+
+		if _, expanded := m.expandedWorkflows[wf.ID]; !expanded {
+			continue
+		}
+
+		for j, e := range wf.Executions {
+			add(workflowSpan{
+				row:        row{kind: rowExecution, workflow: wf.ID, execution: e.ID},
+				height:     1 + countSteps(e.Steps),
+				last:       j == len(wf.Executions)-1 && !wf.MoreExecutions,
+				lastParent: lastWorkflow,
+				workflow:   wf,
+				execution:  e,
+			})
+		}
+
+		if wf.MoreExecutions {
+			add(workflowSpan{
+				row:        row{kind: rowOlderExecutions, workflow: wf.ID},
+				height:     1,
+				last:       true,
+				lastParent: lastWorkflow,
+				workflow:   wf,
+			})
+		}
+	}
+
+	return spans
+}
+
+// countSteps returns the number of steps, including nested steps.
+func countSteps(steps []workflow.Step) int {
+	n := len(steps)
+	for _, s := range steps {
+		n += countSteps(s.Steps)
+	}
+
+	return n
+}
+
+// spansHeight returns the number of lines that the passed spans take up.
+func spansHeight(spans []workflowSpan) int {
+	if len(spans) == 0 {
+		return 0
+	}
+
+	last := spans[len(spans)-1]
+
+	return last.first + last.height
+}
+
+// workflowTreeHeight is the number of lines of the workflow tree that fit in its box.
+func (m Model) workflowTreeHeight() int {
+	// Leave out the borders and the title.
+	const chrome = 3
+
+	return max(m.bottomBoxHeight()-chrome, 1)
+}
+
+// scrollWorkflows scrolls the workflow tree, as little as possible, so that the row under its cursor is in view.
+func (m Model) scrollWorkflows() Model {
+	spans := m.workflowSpans()
+	height := m.workflowTreeHeight()
+
+	if current, ok := m.currentWorkflowRow(); ok {
+		for _, s := range spans {
+			if s.row != current {
+				continue
+			}
+
+			// As much of a tall execution as fits is shown, from its top.
+			bottom := s.first + min(s.height, height)
+
+			if s.first < m.workflowOffset {
+				m.workflowOffset = s.first
+			} else if bottom > m.workflowOffset+height {
+				m.workflowOffset = bottom - height
+			}
+
+			break
+		}
+	}
+
+	// Never leave the tree scrolled past its end, such as after a workflow is collapsed.
+	m.workflowOffset = max(min(m.workflowOffset, spansHeight(spans)-height), 0)
+
+	return m
+}
+
+// renderWorkflowViewport renders the lines of the workflow tree that are scrolled into view, with a scrollbar.
+func (m Model) renderWorkflowViewport(width int) string {
+	spans := m.workflowSpans()
+	height := m.workflowTreeHeight()
+	current, _ := m.currentWorkflowRow()
+
+	var lines []string
+
+	for _, s := range spans {
+		if s.first+s.height <= m.workflowOffset {
+			continue
+		}
+
+		if s.first >= m.workflowOffset+height {
+			break
+		}
+
+		// The first row in view may have started above it.
+		skip := max(m.workflowOffset-s.first, 0)
+		lines = append(lines, m.renderSpan(s, s.row == current)[skip:]...)
+	}
+
+	lines = lines[:min(len(lines), height)]
+
+	// Truncate rather than wrap long lines, so that each row takes up the lines it was counted as.
+	const scrollbarWidth = 1
+
+	truncate := lipgloss.NewStyle().MaxWidth(width - scrollbarWidth)
+	for i, l := range lines {
+		lines[i] = truncate.Render(l)
+	}
+
+	content := lipgloss.NewStyle().
+		Width(width - scrollbarWidth).
+		Height(height).
+		Render(strings.Join(lines, "\n"))
+
+	return lipgloss.JoinHorizontal(0, content, renderScrollbar(height, m.workflowOffset, spansHeight(spans)))
+}
+
+// renderSpan renders each line of a row of the workflow tree, with the branches leading to it.
+func (m Model) renderSpan(s workflowSpan, highlighted bool) []string {
+	var value, branch, indent string
+
+	switch s.row.kind {
+	case rowWorkflow:
+		value = m.renderWorkflow(s.workflow)
+		branch, _ = treeBranches(s.last)
+	case rowExecution, rowOlderExecutions:
+		_, parentIndent := treeBranches(s.lastParent)
+		ownBranch, ownIndent := treeBranches(s.last)
+		branch, indent = parentIndent+ownBranch, parentIndent+ownIndent
+
+		value = m.renderExecution(s.execution)
+		if s.row.kind == rowOlderExecutions {
+			value = lipgloss.NewStyle().Faint(true).Render("… older executions, move past the last to load")
+		}
+	case rowOrganisation, rowEnvironment:
+		// Not part of the workflow tree.
+	}
+
+	if highlighted {
+		value = lipgloss.NewStyle().
+			Background(lipgloss.BrightBlue).
+			Foreground(lipgloss.White).
+			Render(value)
+	}
+
+	lines := []string{branch + value}
+
+	if len(s.execution.Steps) > 0 {
+		steps := tree.Root("")
+		m.addSteps(steps, s.execution.Steps)
+
+		for _, l := range strings.Split(steps.String(), "\n") {
+			lines = append(lines, indent+l)
+		}
+	}
+
+	return lines
+}
+
+// treeBranches returns the branch leading to a child of a tree, and the indent for that child's own children.
+func treeBranches(last bool) (string, string) {
+	if last {
+		return treeLastBranch, treeLastIndent
+	}
+
+	return treeBranch, treeIndent
+}
+
+// renderScrollbar renders a scrollbar of the passed height, for a view that is offset lines into total.
+// Nothing is drawn when everything fits in view, though the space is kept so that the view does not shift.
+func renderScrollbar(height, offset, total int) string {
+	bar := make([]string, height)
+
+	if total <= height {
+		for i := range bar {
+			bar[i] = " "
+		}
+
+		return strings.Join(bar, "\n")
+	}
+
+	thumbSize := max(height*height/total, 1)
+	thumbStart := offset * (height - thumbSize) / (total - height)
+
+	track := lipgloss.NewStyle().Faint(true).Render("│")
+
+	for i := range bar {
+		bar[i] = track
+		if i >= thumbStart && i < thumbStart+thumbSize {
+			bar[i] = "┃"
+		}
+	}
+
+	return strings.Join(bar, "\n")
+}
@@ -0,0 +1,148 @@
+package ui
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"tkview/internal/environment"
+	"tkview/internal/state"
+	"tkview/internal/tkview"
+	"tkview/internal/workflow"
+)
+
+// SessionStore remembers what was being looked at in each testkube instance, between runs.
+type SessionStore interface {
+	Load(key string) (state.Session, error)
+	Save(key string, session state.Session) error
+}
+
+// WithSessions returns a copy of the Model that carries on from the session saved in the store under the passed key.
+// See SaveSession to save it again. Profiles that are switched to use their own SessionKey.
+// A session that cannot be loaded is reported as an error, and the Model starts afresh.
+func (m Model) WithSessions(store SessionStore, key string) Model {
+	m.sessions = store
+	m.sessionKey = key
+
+	session, err := store.Load(key)
+	if err != nil {
+		m.errs = append(m.errs, fmt.Errorf("load session: %w", err))
+
+		return m
+	}
+
+	return m.restoreSession(session)
+}
+
+// SaveSession saves what is currently being looked at, so that the next run can carry on from there.
+// It does nothing if the Model has no SessionStore, or nothing has loaded yet.
+func (m Model) SaveSession() error {
+	// Saving before anything has loaded would forget the last session.
+	if m.sessions == nil || !m.orgsLoaded {
+		return nil
+	}
+
+	if err := m.sessions.Save(m.sessionKey, m.session()); err != nil {
+		return fmt.Errorf("save session: %w", err)
+	}
+
+	return nil
+}
+
+// session returns what is currently being looked at.
+func (m Model) session() state.Session {
+	s := state.Session{
+		ExpandedWorkflows: slices.Sorted(maps.Keys(m.expandedWorkflows)),
+		Focused:           m.focused.String(),
+		Sort:              m.sortMode.String(),
+	}
+
+	if env, err := m.tkview.GetCurrentEnvironment(); err == nil {
+		s.Environment = env.ID
+
+		for _, org := range m.orgs {
+			if slices.ContainsFunc(org.Envs, func(e environment.Environment) bool { return e.ID == env.ID }) {
+				s.Organisation = org.ID
+			}
+		}
+	}
+
+	if wf, err := m.tkview.GetCurrentWorkflow(); err == nil {
+		s.Workflow = wf.ID
+	}
+
+	return s
+}
+
+// restoreSession sets up the Model to carry on from the passed session.
+// The environment and workflow are selected once they have loaded, if they still exist.
+func (m Model) restoreSession(s state.Session) Model {
+	m.restore = s
+
+	for _, id := range s.ExpandedWorkflows {
+		m.expandedWorkflows[id] = struct{}{}
+	}
+
+	for _, v := range []view{viewEnvs, viewAgents, viewWorkflows} {
+		if v.String() == s.Focused {
+			m.focused = v
+		}
+	}
+
+	for mode := range sortModeCount {
+		if mode.String() == s.Sort {
+			m.sortMode = mode
+		}
+	}
+
+	return m
+}
+
+// restoredEnvironment returns the environment of the session being restored, if it still exists.
+func (m Model) restoredEnvironment() (environment.ID, bool) {
+	if m.restore.Environment == "" {
+		return "", false
+	}
+
+	for _, org := range m.orgs {
+		if m.restore.Organisation != "" && m.restore.Organisation != org.ID {
+			continue
+		}
+
+		for _, e := range org.Envs {
+			if e.ID == m.restore.Environment {
+				return e.ID, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// restoredWorkflow returns the workflow of the session being restored, if it is one of the passed workflows.
+func (m Model) restoredWorkflow(workflows []tkview.Workflow) (workflow.ID, bool) {
+	if m.restore.Workflow == "" {
+		return "", false
+	}
+
+	for _, w := range workflows {
+		if w.ID == m.restore.Workflow {
+			return w.ID, true
+		}
+	}
+
+	return "", false
+}
+
+func (v view) String() string {
+	switch v {
+	case viewEnvs:
+		return "environments"
+	case viewAgents:
+		return "agents"
+	case viewWorkflows:
+		return "workflows"
+	}
+
+	return "unknown"
+}
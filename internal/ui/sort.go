@@ -0,0 +1,121 @@
+package ui
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"tkview/internal/tkview"
+
+	"github.com/kubeshop/testkube/pkg/api/v1/testkube"
+)
+
+// sortMode is an order that the workflows can be listed in.
+type sortMode int
+
+const (
+	sortLastRun sortMode = iota
+	sortName
+	sortStatus
+	sortDuration
+	sortFailureRate
+	sortModeCount
+)
+
+func (s sortMode) String() string {
+	switch s {
+	case sortLastRun:
+		return "last run"
+	case sortName:
+		return "name"
+	case sortStatus:
+		return "status"
+	case sortDuration:
+		return "duration"
+	case sortFailureRate:
+		return "failure rate"
+	case sortModeCount:
+	}
+
+	return "unknown"
+}
+
+// next returns the sort mode to cycle on to.
+func (s sortMode) next() sortMode {
+	return (s + 1) % sortModeCount
+}
+
+// needsMetrics reports whether workflows need their metrics loaded to be sorted this way.
+func (s sortMode) needsMetrics() bool {
+	return s == sortDuration || s == sortFailureRate
+}
+
+// sortWorkflows sorts the passed workflows in place.
+// Whatever the mode, ties are broken by the last run, as the most recently run workflows are likely
+// the most interesting for a user, and then by name, so that the order is stable between refreshes.
+func sortWorkflows(workflows []tkview.Workflow, mode sortMode) {
+	slices.SortFunc(workflows, func(a, b tkview.Workflow) int {
+		var c int
+
+		switch mode {
+		case sortName:
+			c = strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		case sortStatus:
+			c = cmp.Compare(statusRank(a.LastExecutionStatus), statusRank(b.LastExecutionStatus))
+		case sortDuration:
+			// Slowest first, workflows without metrics last.
+			c = compareMetrics(a, b, func(w tkview.Workflow) float64 { return float64(w.Metrics.AverageDuration) })
+		case sortFailureRate:
+			// Most failing first, workflows without metrics last.
+			c = compareMetrics(a, b, func(w tkview.Workflow) float64 { return w.Metrics.FailureRate() })
+		case sortLastRun, sortModeCount:
+		}
+
+		return cmp.Or(
+			c,
+			b.LastExecutionAt.Compare(a.LastExecutionAt),
+			strings.Compare(a.Name, b.Name),
+		)
+	})
+}
+
+// compareMetrics orders workflows by the passed value of their metrics, highest first.
+// Workflows without metrics come after those with them.
+func compareMetrics(a, b tkview.Workflow, value func(tkview.Workflow) float64) int {
+	switch {
+	case a.Metrics == nil && b.Metrics == nil:
+		return 0
+	case a.Metrics == nil:
+		return 1
+	case b.Metrics == nil:
+		return -1
+	}
+
+	return cmp.Compare(value(b), value(a))
+}
+
+// statusRank orders the last status of workflows, failures first, then anything still to finish, then passes.
+// Workflows that have never run come last.
+func statusRank(s string) int {
+	switch testkube.TestWorkflowStatus(s) {
+	case testkube.FAILED_TestWorkflowStatus:
+		return 0
+	case testkube.ABORTED_TestWorkflowStatus,
+		testkube.CANCELED_TestWorkflowStatus:
+		return 1
+	case testkube.QUEUED_TestWorkflowStatus,
+		testkube.PENDING_TestWorkflowStatus,
+		testkube.STARTING_TestWorkflowStatus,
+		testkube.SCHEDULING_TestWorkflowStatus,
+		testkube.RUNNING_TestWorkflowStatus,
+		testkube.PAUSING_TestWorkflowStatus,
+		testkube.PAUSED_TestWorkflowStatus,
+		testkube.RESUMING_TestWorkflowStatus,
+		testkube.STOPPING_TestWorkflowStatus:
+		return 2
+	case testkube.PASSED_TestWorkflowStatus:
+		return 3
+	}
+
+	return 4
+}
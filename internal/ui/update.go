@@ -2,11 +2,16 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"sort"
+	"slices"
+	"time"
 
 	"tkview/internal/agent"
 	"tkview/internal/environment"
+	"tkview/internal/state"
+	"tkview/internal/testkube"
 	"tkview/internal/tkview"
 	"tkview/internal/workflow"
 
@@ -16,13 +21,59 @@ import (
 
 type errMsg error
 type focusMsg view
-type orgTreeMsg []tkview.Organisation
 type envMsg environment.ID
-type agentsMsg []agent.Agent
-type workflowTreeMsg []tkview.Workflow
-type workflowTreeUpdateMsg []tkview.Workflow
+
+// orgTreeMsg, orgTreeUpdateMsg, agentsMsg, and the workflow tree messages below, carry the TKView they were loaded from.
+// Not every load can be cancelled, and those served from the cache finish regardless, so they may arrive after switching
+// to another profile.
+type orgTreeMsg struct {
+	tkview *tkview.TKView
+	orgs   []tkview.Organisation
+}
+
+type orgTreeUpdateMsg struct {
+	tkview *tkview.TKView
+	orgs   []tkview.Organisation
+}
+
+type agentsMsg struct {
+	tkview *tkview.TKView
+	agents []agent.Agent
+}
+
+type workflowTreeMsg struct {
+	tkview    *tkview.TKView
+	workflows []tkview.Workflow
+	// revalidating is set when some of the workflows were stale, and are still being loaded again.
+	revalidating bool
+}
+
+type workflowTreeUpdateMsg struct {
+	tkview    *tkview.TKView
+	workflows []tkview.Workflow
+}
 type workflowMsg workflow.ID
 type toggleWorkflowMsg workflow.ID
+type executionStartedMsg workflow.ID
+type executionMsg workflow.ExecutionID
+type deselectExecutionMsg struct{}
+type confirmAbortMsg workflow.ExecutionID
+type tickMsg time.Time
+type refreshMsg struct{}
+
+// refreshedMsg carries the refreshed workflow tree, along with anything that could not be refreshed within it.
+type refreshedMsg struct {
+	tkview       *tkview.TKView
+	workflows    []tkview.Workflow
+	errs         []error
+	revalidating bool
+}
+type executionSelectedMsg workflow.ExecutionID
+type olderExecutionsMsg workflow.ID
+type sortMsg struct{}
+
+// revalidatedMsg is sent when the passed TKView has loaded stale data again in the background.
+type revalidatedMsg struct{ tkview *tkview.TKView }
 
 func errCmd(err error) tea.Cmd {
 	return func() tea.Msg {
@@ -30,6 +81,12 @@ func errCmd(err error) tea.Cmd {
 	}
 }
 
+func refreshCmd() tea.Cmd {
+	return func() tea.Msg {
+		return refreshMsg{}
+	}
+}
+
 func focusCmd(v view) tea.Cmd {
 	return func() tea.Msg {
 		return focusMsg(v)
@@ -49,34 +106,104 @@ func switchWorkflowCmd(id workflow.ID) tea.Cmd {
 }
 
 // Update responds to tea messages to create a new model implementation.
-//
-//nolint:cyclop,gocyclo,funlen // This is going to be a big function because it handles all the UI update logic.
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+
+	// Whatever the message changed, keep the cursor of the workflow tree in view.
+	if next, ok := next.(Model); ok {
+		return next.scrollWorkflows(), cmd
+	}
+
+	return next, cmd
+}
+
+//nolint:cyclop,gocyclo,funlen // This is going to be a big function because it handles all the UI update logic.
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// Basic messages for the general good behaviour of the program.
 	switch msg := msg.(type) {
 	case tea.KeyPressMsg:
 		switch {
 		case key.Matches(msg.Key(), m.keyMap.Quit):
 			return m, tea.Quit
-		case key.Matches(msg.Key(), m.keyMap.Next):
-			switch m.focused {
-			case viewEnvs:
-				return m, m.nextOrgEnv
-			case viewAgents:
-			case viewWorkflows:
-				return m, m.nextWorkflow
+		case m.editingFilter():
+			return m.updateFilter(msg)
+		case m.confirmAbort != "":
+			// Any key other than confirm cancels the abort.
+			id := m.confirmAbort
+			m.confirmAbort = ""
+
+			if key.Matches(msg.Key(), m.keyMap.Confirm) {
+				return m, m.abortExecution(m.profileLoad, id)
 			}
+
+			return m, nil
+		case key.Matches(msg.Key(), m.keyMap.OlderError):
+			m.errIndex = min(m.errIndex+1, max(len(m.errs)-1, 0))
+
+			return m, nil
+		case key.Matches(msg.Key(), m.keyMap.NewerError):
+			m.errIndex = max(m.errIndex-1, 0)
+
+			return m, nil
+		case key.Matches(msg.Key(), m.keyMap.ClearErrors):
+			m.errs = nil
+			m.errIndex = 0
+
+			return m, nil
+		case key.Matches(msg.Key(), m.keyMap.Refresh) && !m.logs.isOpen() && !m.picker.open:
+			// Anything cached is still shown whilst it loads afresh.
+			m.tkview.Expire()
+
+			return m, refreshCmd()
+		case m.logs.isOpen():
+			return m.updateLogs(msg)
+		case m.picker.open:
+			return m.updateProfilePicker(msg)
+		case key.Matches(msg.Key(), m.keyMap.Profiles) && len(m.profiles) > 0:
+			return m.openProfilePicker()
+		case key.Matches(msg.Key(), m.keyMap.Filter):
+			return m.startFilter()
+		case key.Matches(msg.Key(), m.keyMap.Close):
+			return m.clearFilter()
+		case key.Matches(msg.Key(), m.keyMap.Next):
+			return m.moveCursor(1, true)
 		case key.Matches(msg.Key(), m.keyMap.Prev):
+			return m.moveCursor(-1, true)
+		case key.Matches(msg.Key(), m.keyMap.PageDown):
+			return m.moveCursor(m.pageSize(), false)
+		case key.Matches(msg.Key(), m.keyMap.PageUp):
+			return m.moveCursor(-m.pageSize(), false)
+		case key.Matches(msg.Key(), m.keyMap.First):
+			return m.moveToFirst()
+		case key.Matches(msg.Key(), m.keyMap.Last):
+			return m.moveToLast()
+		case key.Matches(msg.Key(), m.keyMap.Expand):
+			return m.expand()
+		case key.Matches(msg.Key(), m.keyMap.Collapse):
+			return m.collapse()
+		case key.Matches(msg.Key(), m.keyMap.Select):
 			switch m.focused {
 			case viewEnvs:
-				return m, m.prevOrgEnv
+				return m, m.retryOrganisation()
 			case viewAgents:
 			case viewWorkflows:
-				return m, m.prevWorkflow
+				return m, m.toggleWorkflow
 			}
-		case key.Matches(msg.Key(), m.keyMap.Select):
+		case key.Matches(msg.Key(), m.keyMap.Sort):
 			if m.focused == viewWorkflows {
-				return m, m.toggleWorkflow
+				return m, func() tea.Msg { return sortMsg{} }
+			}
+		case key.Matches(msg.Key(), m.keyMap.Start):
+			if m.focused == viewWorkflows {
+				return m, m.startExecution(m.profileLoad)
+			}
+		case key.Matches(msg.Key(), m.keyMap.Abort):
+			if m.focused == viewWorkflows {
+				return m, m.requestAbort
+			}
+		case key.Matches(msg.Key(), m.keyMap.Logs):
+			if m.focused == viewWorkflows {
+				return m.openLogs()
 			}
 		case key.Matches(msg.Key(), m.keyMap.FocusEnvironments):
 			return m, focusCmd(viewEnvs)
@@ -88,50 +215,127 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		m.logs = m.logs.setSize(m.width, m.bottomBoxHeight())
 
 		return m, nil
 	case errMsg:
-		// TODO: display errors
-		panic(msg)
+		// Loads that were cancelled because they were superseded are not errors.
+		if errors.Is(msg, context.Canceled) {
+			return m, nil
+		}
+
+		// Keep the most recent errors for display, and carry on with whatever data was last loaded.
+		m.errs = append([]error{msg}, m.errs...)
+		if len(m.errs) > uiMaxErrorHistory {
+			m.errs = m.errs[:uiMaxErrorHistory]
+		}
+
+		m.errIndex = 0
+
+		// An agent that times out has not said there are no workflows, so keep showing the last known ones, but mark them as such.
+		if errors.Is(msg, testkube.ErrAgentTimedOut) {
+			m.agentTimedOut = true
+		}
+
+		return m, nil
 	case orgTreeMsg:
-		m.orgs = msg
+		if msg.tkview != m.tkview {
+			return m, nil
+		}
+
+		m.orgs = msg.orgs
+		m.orgsLoaded = true
 
-		// Select the initial environment.
-		// TODO: this is dangerous, the first org might not have any environments!
-		return m, switchEnvCmd(msg[0].Envs[0].ID)
+		// Organisations whose environments failed to load are marked in the tree, the errors say why.
+		var cmds []tea.Cmd
+
+		for _, org := range msg.orgs {
+			if org.Err != nil {
+				cmds = append(cmds, errCmd(org.Err))
+			}
+		}
+
+		var cmd tea.Cmd
+		m, cmd = m.selectInitialEnvironment()
+
+		return m, tea.Batch(append(cmds, cmd)...)
+	case orgTreeUpdateMsg:
+		if msg.tkview != m.tkview {
+			return m, nil
+		}
+
+		m.orgs = msg.orgs
+
+		// If every organisation failed to load before, there is now something to select.
+		if _, err := m.tkview.GetCurrentEnvironment(); err != nil {
+			return m.selectInitialEnvironment()
+		}
+
+		return m, nil
 	case envMsg:
 		err := m.tkview.SelectEnvironment(environment.ID(msg))
 		if err != nil {
 			return m, errCmd(err)
 		}
 
-		// After the environment changes, load the agents and executions again.
+		m.orgCursor = ""
+
+		// After the environment changes, load the agents and executions again,
+		// abandoning anything still loading for the previous environment.
+		ctx := m.startLoad(loadEnv, loadWorkflow, loadExecution, loadRefresh, loadOlderExecutions, loadMetrics, loadWorkflowTree)
+		m.agentTimedOut = false
+
 		return m, tea.Batch(
-			m.loadAgents,
-			m.loadWorkflowTree,
+			m.loadAgents(ctx),
+			m.loadWorkflowTree(ctx),
 		)
 	case agentsMsg:
-		m.agents = msg
+		if msg.tkview != m.tkview {
+			return m, nil
+		}
+
+		m.agents = msg.agents
 
 		return m, nil
 	case workflowTreeMsg:
-		// Sort workflows by the last execution time as these are likely
-		// the most interesting for a user.
-		sort.Slice(msg, func(i, j int) bool {
-			return msg[i].LastExecutionAt.After(msg[j].LastExecutionAt)
-		})
+		if msg.tkview != m.tkview {
+			return m, nil
+		}
+
+		sortWorkflows(msg.workflows, m.sortMode)
+
+		m.workflows = msg.workflows
+		m = m.refreshed(msg.revalidating)
+		m.agentTimedOut = false
+
+		if len(msg.workflows) == 0 {
+			return m, nil
+		}
+
+		// Select the workflow of the last session, if it still exists, otherwise the first.
+		first, ok := m.restoredWorkflow(msg.workflows)
+		if !ok {
+			first = msg.workflows[0].ID
+		}
+
+		// A session is only restored once.
+		m.restore = state.Session{}
 
-		m.workflows = msg
+		if m.sortMode.needsMetrics() {
+			return m, tea.Batch(switchWorkflowCmd(first), m.loadMetrics(m.startLoad(loadMetrics)))
+		}
 
-		return m, switchWorkflowCmd(msg[0].ID)
+		return m, switchWorkflowCmd(first)
 	case workflowTreeUpdateMsg:
-		// Sort workflows by the last execution time as these are likely
-		// the most interesting for a user.
-		sort.Slice(msg, func(i, j int) bool {
-			return msg[i].LastExecutionAt.After(msg[j].LastExecutionAt)
-		})
+		if msg.tkview != m.tkview {
+			return m, nil
+		}
 
-		m.workflows = msg
+		// The cursor follows the selected workflow, so stays on it however the order changes.
+		sortWorkflows(msg.workflows, m.sortMode)
+
+		m.workflows = msg.workflows
+		m.agentTimedOut = false
 
 		// Check the selected workflow still exists.
 		currentWorkflow, err := m.tkview.GetCurrentWorkflow()
@@ -145,16 +349,16 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
-		// If not, switch to the first workflow again.
+		// If not, switch to the first workflow again, if there are any left.
+		if len(m.workflows) == 0 {
+			return m, nil
+		}
+
 		return m, switchWorkflowCmd(m.workflows[0].ID)
 	case workflowMsg:
-		err := m.tkview.SelectWorkflow(workflow.ID(msg))
-		if err != nil {
-			return m, errCmd(err)
-		}
+		ctx := m.startLoad(loadWorkflow, loadExecution, loadOlderExecutions, loadWorkflowTree)
 
-		// After the workflow is selected, update the workflow tree.
-		return m, m.updateWorkflowTree
+		return m, m.selectWorkflow(ctx, workflow.ID(msg))
 	case toggleWorkflowMsg:
 		id := workflow.ID(msg)
 
@@ -166,155 +370,405 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		delete(m.expandedWorkflows, id)
+		// A collapsed workflow has no visible executions to select.
+		m.tkview.DeselectExecution()
+
+		return m, nil
+	case executionStartedMsg:
+		// Expand the workflow so that the new execution is visible at the top.
+		m.expandedWorkflows[workflow.ID(msg)] = struct{}{}
+
+		return m, m.updateWorkflowTree(m.startLoad(loadWorkflowTree))
+	case executionMsg:
+		ctx := m.startLoad(loadExecution)
+
+		return m, m.selectExecution(ctx, workflow.ExecutionID(msg))
+	case executionSelectedMsg:
+		// The steps of the execution are now loaded and ready to be displayed.
+		return m, m.updateWorkflowTree(m.startLoad(loadWorkflowTree))
+	case sortMsg:
+		m.sortMode = m.sortMode.next()
+		sortWorkflows(m.workflows, m.sortMode)
+
+		if m.sortMode.needsMetrics() {
+			return m, m.loadMetrics(m.startLoad(loadMetrics))
+		}
+
+		return m, nil
+	case olderExecutionsMsg:
+		ctx := m.startLoad(loadOlderExecutions)
+
+		return m, m.loadOlderExecutions(ctx, workflow.ID(msg))
+	case deselectExecutionMsg:
+		m.tkview.DeselectExecution()
+
+		return m, nil
+	case confirmAbortMsg:
+		m.confirmAbort = workflow.ExecutionID(msg)
 
 		return m, nil
+	case logLinesMsg:
+		// Ignore lines from any log stream that is no longer being viewed.
+		if msg.id != m.logs.execution.ID {
+			return m, nil
+		}
+
+		m.logs = m.logs.addLines(msg.lines)
+
+		return m, waitForLogs(msg.id, msg.more)
+	case logsDoneMsg:
+		return m, nil
+	case tickMsg:
+		return m, tea.Batch(
+			refreshCmd(),
+			m.tick(),
+		)
+	case refreshMsg:
+		// Nothing to refresh until an environment has been selected.
+		if _, err := m.tkview.GetCurrentEnvironment(); err != nil {
+			return m, nil
+		}
+
+		ctx := m.startLoad(loadRefresh)
+
+		return m, tea.Batch(
+			m.loadAgents(ctx),
+			m.refreshWorkflowTree(ctx, m.expandedInTree()),
+		)
+	case refreshedMsg:
+		if msg.tkview != m.tkview {
+			return m, nil
+		}
+
+		m = m.refreshed(msg.revalidating)
+
+		next, cmd := m.Update(workflowTreeUpdateMsg{tkview: msg.tkview, workflows: msg.workflows})
+
+		cmds := []tea.Cmd{cmd}
+		for _, err := range msg.errs {
+			cmds = append(cmds, errCmd(err))
+		}
+
+		return next, tea.Batch(cmds...)
+	case revalidatedMsg:
+		// Ignore anything from the TKView of a profile that has since been switched away from.
+		if msg.tkview != m.tkview {
+			return m, nil
+		}
+
+		cmds := []tea.Cmd{m.waitForRevalidation(m.startLoad(loadRevalidation))}
+
+		// Show whatever was revalidated, which is now fresh, so loading it again is quick.
+		if m.orgsLoaded {
+			cmds = append(cmds, m.updateOrgTree, refreshCmd())
+		}
+
+		return m, tea.Batch(cmds...)
 	case focusMsg:
 		m.focused = view(msg)
 
 		return m, nil
 	}
 
+	// Anything else, such as the cursor blinking, is for the filter being typed into.
+	if f, ok := m.filterOf(m.focused); ok && f.editing {
+		var cmd tea.Cmd
+		f.input, cmd = f.input.Update(msg)
+
+		return m.withFilter(m.focused, f), cmd
+	}
+
 	return m, nil
 }
 
+func (m Model) updateLogs(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg.Key(), m.keyMap.Close):
+		m.logs = m.logs.close()
+	case key.Matches(msg.Key(), m.keyMap.Follow):
+		m.logs = m.logs.toggleFollow()
+	case key.Matches(msg.Key(), m.keyMap.NextStep):
+		m.logs = m.logs.nextStep()
+	case key.Matches(msg.Key(), m.keyMap.PrevStep):
+		m.logs = m.logs.prevStep()
+	default:
+		m.logs = m.logs.scroll(msg)
+	}
+
+	return m, nil
+}
+
+// selectInitialEnvironment selects the environment to start with, if there is one.
+func (m Model) selectInitialEnvironment() (Model, tea.Cmd) {
+	// Carry on from the last session, if its environment still exists.
+	if id, ok := m.restoredEnvironment(); ok {
+		return m, switchEnvCmd(id)
+	}
+
+	// Otherwise, its workflow will not be found either.
+	m.restore = state.Session{}
+
+	// Select the initial environment, preferring the default environment if there is one.
+	if id, ok := m.defaultEnvironment(); ok {
+		return m, switchEnvCmd(id)
+	}
+
+	// If there are no environments at all, there is nothing more to load, and the empty state is shown instead.
+	if id, ok := m.firstEnvironment(); ok {
+		return m, switchEnvCmd(id)
+	}
+
+	// Unless some organisations failed to load, then the cursor starts on the first, ready to retry it.
+	if i := slices.IndexFunc(m.orgs, func(o tkview.Organisation) bool { return o.Err != nil }); i >= 0 {
+		m.orgCursor = m.orgs[i].ID
+	}
+
+	return m, nil
+}
+
+// defaultEnvironment finds the default environment, matching by either name or ID, within the known organisations.
+func (m Model) defaultEnvironment() (environment.ID, bool) {
+	if m.defaultEnv == "" {
+		return "", false
+	}
+
+	for _, o := range m.orgs {
+		if m.defaultOrg != "" && m.defaultOrg != o.Name && m.defaultOrg != string(o.ID) {
+			continue
+		}
+
+		for _, e := range o.Envs {
+			if m.defaultEnv == e.Name || m.defaultEnv == string(e.ID) {
+				return e.ID, true
+			}
+		}
+	}
+
+	return "", false
+}
+
 func (m Model) getOrgTree() tea.Msg {
-	t, err := m.tkview.GetOrganisationTree()
+	t, err := m.tkview.GetOrganisationTree(m.profileLoad)
 	if err != nil {
 		return errMsg(fmt.Errorf("get organisation tree: %w", err))
 	}
 
-	return orgTreeMsg(t)
+	return orgTreeMsg{tkview: m.tkview, orgs: t}
 }
 
-func (m Model) nextOrgEnv() tea.Msg {
-	currentEnv, err := m.tkview.GetCurrentEnvironment()
+// updateOrgTree loads the organisation tree again, without changing what is selected.
+func (m Model) updateOrgTree() tea.Msg {
+	t, err := m.tkview.GetOrganisationTree(m.profileLoad)
 	if err != nil {
-		return errMsg(fmt.Errorf("get current environment: %w", err))
-	}
-
-	for i, o := range m.orgs {
-		for j, e := range o.Envs {
-			if e.ID == currentEnv.ID {
-				switch {
-				case i+1 == len(m.orgs) && j+1 == len(o.Envs):
-					// Nowhere to go, loop back to the start.
-					// TODO: this is dangerous, the first org might not have any environments!
-					return envMsg(m.orgs[0].Envs[0].ID)
-				case j+1 == len(o.Envs):
-					// All out of envs in this org, go to the next org and select the first environment there.
-					// TODO: this is dangerous, the next org might not have any environments!
-					return envMsg(m.orgs[i+1].Envs[0].ID)
-				default:
-					// Go to the next environment in this org.
-					// This should be safe because of the length checks that have occurred above.
-					return envMsg(m.orgs[i].Envs[j+1].ID)
-				}
+		return errMsg(fmt.Errorf("update organisation tree: %w", err))
+	}
+
+	return orgTreeUpdateMsg{tkview: m.tkview, orgs: t}
+}
+
+// retryOrganisation loads the environments of the organisation under the cursor again, if they failed to load.
+func (m Model) retryOrganisation() tea.Cmd {
+	current, ok := m.currentRow()
+	if !ok {
+		return nil
+	}
+
+	for _, org := range m.orgs {
+		if org.ID != current.org || org.Err == nil {
+			continue
+		}
+
+		return func() tea.Msg {
+			t, err := m.tkview.RefreshEnvironments(m.profileLoad, org.ID)
+			if err != nil {
+				return errMsg(fmt.Errorf("retry organisation: %w", err))
 			}
+
+			return orgTreeUpdateMsg{tkview: m.tkview, orgs: t}
 		}
 	}
-	// This shouldn't happen, but could.
-	// TODO: probably do something here
+
 	return nil
 }
 
-func (m Model) prevOrgEnv() tea.Msg {
-	currentEnv, err := m.tkview.GetCurrentEnvironment()
-	if err != nil {
-		return errMsg(fmt.Errorf("get current environment: %w", err))
-	}
-
-	for i, o := range m.orgs {
-		for j, e := range o.Envs {
-			if e.ID == currentEnv.ID {
-				switch {
-				case i-1 < 0 && j-1 < 0:
-					// Nowhere to go, loop back to the bottom.
-					// TODO: this is dangerous, the last org might not have any environments!
-					return envMsg(m.orgs[len(m.orgs)-1].Envs[len(m.orgs[len(m.orgs)-1].Envs)-1].ID)
-				case j-1 < 0:
-					// All out of envs in this org, go to the previous org and select the last environment there.
-					// TODO: this is dangerous, the previous org might not have any environments!
-					return envMsg(m.orgs[i-1].Envs[len(m.orgs[i-1].Envs)-1].ID)
-				default:
-					// Go to the previous environment in this org.
-					// This should be safe because of the checks that have occurred above.
-					return envMsg(m.orgs[i].Envs[j-1].ID)
-				}
-			}
+func (m Model) loadAgents(ctx context.Context) tea.Cmd {
+	return func() tea.Msg {
+		agents, err := m.tkview.GetAgents(ctx)
+		if err != nil {
+			return errMsg(fmt.Errorf("get agents: %w", err))
 		}
+
+		return agentsMsg{tkview: m.tkview, agents: agents}
 	}
-	// This shouldn't happen, but could.
-	// TODO: probably do something here
-	return nil
 }
 
-func (m Model) loadAgents() tea.Msg {
-	agents, err := m.tkview.GetAgents()
-	if err != nil {
-		return errMsg(fmt.Errorf("get agents: %w", err))
+// refreshed records that the workflow tree has been loaded, and when, unless some of it was stale and is
+// still being loaded again. Then it is only refreshed once that has finished, see revalidatedMsg.
+func (m Model) refreshed(revalidating bool) Model {
+	m.workflowsLoaded = true
+	m.revalidating = revalidating
+
+	if !revalidating {
+		m.lastRefresh = m.now()
 	}
 
-	return agentsMsg(agents)
+	return m
 }
 
-func (m Model) loadWorkflowTree() tea.Msg {
-	workflowTree, err := m.tkview.GetWorkflowTree()
-	if err != nil {
-		return errMsg(fmt.Errorf("get workflow tree: %w", err))
+func (m Model) loadWorkflowTree(ctx context.Context) tea.Cmd {
+	return func() tea.Msg {
+		workflowTree, err := m.tkview.GetWorkflowTree(ctx)
+		if err != nil {
+			return errMsg(fmt.Errorf("get workflow tree: %w", err))
+		}
+
+		return workflowTreeMsg{tkview: m.tkview, workflows: workflowTree, revalidating: m.tkview.Revalidating()}
 	}
+}
+
+func (m Model) updateWorkflowTree(ctx context.Context) tea.Cmd {
+	return func() tea.Msg {
+		workflowTree, err := m.tkview.GetWorkflowTree(ctx)
+		if err != nil {
+			return errMsg(fmt.Errorf("get workflow tree: %w", err))
+		}
 
-	return workflowTreeMsg(workflowTree)
+		return workflowTreeUpdateMsg{tkview: m.tkview, workflows: workflowTree}
+	}
 }
 
-func (m Model) updateWorkflowTree() tea.Msg {
-	workflowTree, err := m.tkview.GetWorkflowTree()
-	if err != nil {
-		return errMsg(fmt.Errorf("get workflow tree: %w", err))
+// selectWorkflow selects the passed workflow, loading its executions, and then updates the workflow tree.
+func (m Model) selectWorkflow(ctx context.Context, id workflow.ID) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.tkview.SelectWorkflow(ctx, id); err != nil {
+			return errMsg(fmt.Errorf("select workflow: %w", err))
+		}
+
+		return m.updateWorkflowTree(ctx)()
 	}
+}
+
+// selectWorkflowExecution selects the passed workflow, loading its executions, and then the passed execution of it.
+func (m Model) selectWorkflowExecution(ctx context.Context, workflowID workflow.ID, executionID workflow.ExecutionID) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.tkview.SelectWorkflow(ctx, workflowID); err != nil {
+			return errMsg(fmt.Errorf("select workflow: %w", err))
+		}
 
-	return workflowTreeUpdateMsg(workflowTree)
+		return executionMsg(executionID)
+	}
 }
 
-func (m Model) nextWorkflow() tea.Msg {
-	currentWorkflow, err := m.tkview.GetCurrentWorkflow()
-	if err != nil {
-		return errMsg(fmt.Errorf("get current workflow: %w", err))
+// selectExecution selects the passed execution, loading its steps.
+func (m Model) selectExecution(ctx context.Context, id workflow.ExecutionID) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.tkview.SelectExecution(ctx, id); err != nil {
+			return errMsg(fmt.Errorf("select execution: %w", err))
+		}
+
+		return executionSelectedMsg(id)
+	}
+}
+
+// loadOlderExecutions loads the next page of executions of the passed workflow, and then selects the first of them.
+func (m Model) loadOlderExecutions(ctx context.Context, id workflow.ID) tea.Cmd {
+	return func() tea.Msg {
+		older, err := m.tkview.LoadOlderExecutions(ctx, id)
+		if err != nil {
+			return errMsg(fmt.Errorf("load older executions: %w", err))
+		}
+
+		if len(older) == 0 {
+			// There turned out to be nothing older, which the tree should now show.
+			return m.updateWorkflowTree(ctx)()
+		}
+
+		return executionMsg(older[0].ID)
 	}
+}
 
-	for i, w := range m.workflows {
-		if w.ID == currentWorkflow.ID {
-			if i+1 == len(m.workflows) {
-				// Nowhere to go, loop back to the start.
-				return workflowMsg(m.workflows[0].ID)
-			}
-			// Go to the next workflow
-			return workflowMsg(m.workflows[i+1].ID)
+// loadMetrics loads the metrics of the workflows, for sorting by them.
+func (m Model) loadMetrics(ctx context.Context) tea.Cmd {
+	return func() tea.Msg {
+		workflows, err := m.tkview.LoadMetrics(ctx)
+		if err != nil {
+			return errMsg(fmt.Errorf("load metrics: %w", err))
 		}
+
+		return workflowTreeUpdateMsg{tkview: m.tkview, workflows: workflows}
 	}
-	// This shouldn't happen, but could.
-	// TODO: probably do something here
-	return nil
 }
 
-func (m Model) prevWorkflow() tea.Msg {
-	currentWorkflow, err := m.tkview.GetCurrentWorkflow()
-	if err != nil {
-		return errMsg(fmt.Errorf("get current workflow: %w", err))
+// waitForRevalidation waits for the TKView to load stale data again in the background, unless it never does.
+func (m Model) waitForRevalidation(ctx context.Context) tea.Cmd {
+	revalidated := m.tkview.Revalidated()
+	if revalidated == nil {
+		return nil
 	}
 
-	for i, w := range m.workflows {
-		if w.ID == currentWorkflow.ID {
-			if i-1 < 0 {
-				// Nowhere to go, loop back to the end.
-				return workflowMsg(m.workflows[len(m.workflows)-1].ID)
+	tk := m.tkview
+
+	return func() tea.Msg {
+		select {
+		case <-revalidated:
+			return revalidatedMsg{tkview: tk}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// tick schedules the next periodic refresh, unless periodic refreshing is disabled.
+func (m Model) tick() tea.Cmd {
+	if m.refreshInterval <= 0 {
+		return nil
+	}
+
+	return tea.Tick(m.refreshInterval, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
+
+// refreshWorkflowTree reloads the executions of the passed expanded workflows,
+// and the steps of the current execution, before reloading the workflow tree itself.
+// Whatever fails to refresh is left as it was, rather than stopping the rest from refreshing.
+func (m Model) refreshWorkflowTree(ctx context.Context, expanded []workflow.ID) tea.Cmd {
+	return func() tea.Msg {
+		var errs []error
+
+		for _, id := range expanded {
+			if err := m.tkview.RefreshExecutions(ctx, id); err != nil {
+				errs = append(errs, fmt.Errorf("refresh executions: %w", err))
+			}
+		}
+
+		if currentExecution, err := m.tkview.GetCurrentExecution(); err == nil {
+			if err := m.tkview.SelectExecution(ctx, currentExecution.ID); err != nil {
+				errs = append(errs, fmt.Errorf("refresh steps: %w", err))
 			}
-			// Go to the previous workflow
-			return workflowMsg(m.workflows[i-1].ID)
 		}
+
+		workflowTree, err := m.tkview.GetWorkflowTree(ctx)
+		if err != nil {
+			return errMsg(fmt.Errorf("get workflow tree: %w", err))
+		}
+
+		return refreshedMsg{tkview: m.tkview, workflows: workflowTree, errs: errs, revalidating: m.tkview.Revalidating()}
 	}
-	// This shouldn't happen, but could.
-	// TODO: probably do something here
-	return nil
+}
+
+// expandedInTree returns the expanded workflows that are in the workflow tree.
+// Workflows stay expanded when they are deleted, or the environment changes, but there is nothing to refresh for them.
+func (m Model) expandedInTree() []workflow.ID {
+	var ids []workflow.ID
+
+	for _, w := range m.workflows {
+		if _, ok := m.expandedWorkflows[w.ID]; ok {
+			ids = append(ids, w.ID)
+		}
+	}
+
+	return ids
 }
 
 func (m Model) toggleWorkflow() tea.Msg {
@@ -325,3 +779,44 @@ func (m Model) toggleWorkflow() tea.Msg {
 
 	return toggleWorkflowMsg(currentWorkflow.ID)
 }
+
+func (m Model) startExecution(ctx context.Context) tea.Cmd {
+	return func() tea.Msg {
+		currentWorkflow, err := m.tkview.GetCurrentWorkflow()
+		if err != nil {
+			return errMsg(fmt.Errorf("get current workflow: %w", err))
+		}
+
+		if _, err := m.tkview.StartExecution(ctx, currentWorkflow.ID); err != nil {
+			return errMsg(fmt.Errorf("start execution: %w", err))
+		}
+
+		return executionStartedMsg(currentWorkflow.ID)
+	}
+}
+
+func (m Model) requestAbort() tea.Msg {
+	currentExecution, err := m.tkview.GetCurrentExecution()
+	if err != nil {
+		// Nothing to abort without a selected execution.
+		return nil
+	}
+
+	return confirmAbortMsg(currentExecution.ID)
+}
+
+func (m Model) abortExecution(ctx context.Context, id workflow.ExecutionID) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.tkview.AbortExecution(ctx, id); err != nil {
+			return errMsg(fmt.Errorf("abort execution: %w", err))
+		}
+
+		currentWorkflow, err := m.tkview.GetCurrentWorkflow()
+		if err != nil {
+			return errMsg(fmt.Errorf("get current workflow: %w", err))
+		}
+
+		// Reselect the workflow to refresh the status of its executions.
+		return workflowMsg(currentWorkflow.ID)
+	}
+}
@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"tkview/internal/tkview"
+
+	"github.com/charmbracelet/bubbletea/v2"
+)
+
+func TestWorkflowTreeOfAnotherTKViewIgnored(t *testing.T) {
+	m := newTestModel(&fakeClient{})
+	m = settle(t, m, tea.WindowSizeMsg{Width: 120, Height: 40}, m.Init())
+
+	want := len(m.workflows)
+	if want == 0 {
+		t.Fatal("no workflows loaded")
+	}
+
+	// As if loaded for a profile that has since been switched away from.
+	other := tkview.New(&fakeClient{})
+
+	for _, msg := range []tea.Msg{
+		workflowTreeMsg{tkview: other},
+		workflowTreeUpdateMsg{tkview: other},
+		refreshedMsg{tkview: other},
+	} {
+		m = settle(t, m, msg, nil)
+
+		if got := len(m.workflows); got != want {
+			t.Errorf("after %T of another TKView, %d workflows, want %d", msg, got, want)
+		}
+	}
+}
+
+func TestSwitchProfileCancelsLoads(t *testing.T) {
+	m := newTestModel(&fakeClient{})
+	m = settle(t, m, tea.WindowSizeMsg{Width: 120, Height: 40}, m.Init())
+
+	// Starting and aborting executions, and reloading the tree after selecting one, use these.
+	profile := m.profileLoad
+	tree := m.startLoad(loadWorkflowTree)
+
+	next, _ := m.switchProfile(Profile{
+		Name:    "other",
+		Connect: func() (*tkview.TKView, error) { return tkview.New(&fakeClient{}), nil },
+	})
+
+	for name, ctx := range map[string]context.Context{"profile": profile, "workflow tree": tree} {
+		if err := ctx.Err(); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s load of the previous profile error = %v, want %v", name, err, context.Canceled)
+		}
+	}
+
+	m, ok := next.(Model)
+	if !ok {
+		t.Fatalf("switchProfile() returned %T, want a Model", next)
+	}
+
+	if err := m.profileLoad.Err(); err != nil {
+		t.Errorf("profile load of the new profile error = %v, want none", err)
+	}
+}
+
+func TestRefreshKeyIgnoredWhilstPickingProfile(t *testing.T) {
+	m := newTestModel(&fakeClient{}).WithProfiles("test", []Profile{{Name: "test"}, {Name: "other"}})
+	m = settle(t, m, tea.WindowSizeMsg{Width: 120, Height: 40}, m.Init())
+	m = settle(t, m, keys("P")[0], nil)
+
+	if !m.picker.open {
+		t.Fatal("profile picker is not open")
+	}
+
+	next, cmd := m.Update(keys("r")[0])
+	if cmd != nil {
+		t.Errorf("r whilst picking a profile returned a command, sending %T, want none", cmd())
+	}
+
+	if m, ok := next.(Model); !ok || !m.picker.open {
+		t.Error("r whilst picking a profile closed the picker")
+	}
+}
+
+func TestSwitchProfileClearsFiltersAndScrolling(t *testing.T) {
+	m := newTestModel(&fakeClient{})
+	m = settle(t, m, tea.WindowSizeMsg{Width: 120, Height: 40}, m.Init())
+
+	for _, msg := range keys("W", "/", "a", "p", "i", "enter", "E", "/", "p", "r", "o") {
+		m = settle(t, m, msg, nil)
+	}
+
+	m.workflowOffset = 2
+
+	next, _ := m.switchProfile(Profile{
+		Name:    "other",
+		Connect: func() (*tkview.TKView, error) { return tkview.New(&fakeClient{}), nil },
+	})
+
+	m, ok := next.(Model)
+	if !ok {
+		t.Fatalf("switchProfile() returned %T, want a Model", next)
+	}
+
+	for name, f := range map[string]filter{"environment": m.envFilter, "workflow": m.workflowFilter} {
+		if f.shown() {
+			t.Errorf("%s filter %q is still shown after switching profile", name, f.query())
+		}
+	}
+
+	if m.workflowOffset != 0 {
+		t.Errorf("workflowOffset = %d after switching profile, want 0", m.workflowOffset)
+	}
+}
@@ -1,11 +1,14 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	"tkview/internal/tkview"
+	"tkview/internal/workflow"
 
 	"github.com/charmbracelet/lipgloss/v2"
 	"github.com/charmbracelet/lipgloss/v2/table"
@@ -16,17 +19,33 @@ import (
 
 // View renders the model for display on the terminal.
 func (m Model) View() string {
+	bottom := m.renderWorkflows()
+
+	switch {
+	case m.picker.open:
+		bottom = m.renderProfilePicker()
+	case m.logs.isOpen():
+		bottom = m.renderLogs()
+	}
+
 	frame := lipgloss.JoinVertical(0,
 		lipgloss.JoinHorizontal(0,
 			m.renderOrganisations(),
 			m.renderAgents(),
 		),
-		m.renderWorkflows(),
+		bottom,
+		m.renderErrors(),
 	)
 
 	return frame
 }
 
+// bottomBoxHeight is the height available to the workflows, or logs, box
+// after the top boxes and the error box have taken their share.
+func (m Model) bottomBoxHeight() int {
+	return m.height - m.topBoxHeight - m.errorBoxHeight
+}
+
 func (m Model) renderOrganisations() string {
 	box := lipgloss.NewStyle().
 		Border(lipgloss.NormalBorder(), true, true, true, true).
@@ -37,27 +56,61 @@ func (m Model) renderOrganisations() string {
 		box = box.BorderStyle(lipgloss.DoubleBorder())
 	}
 
+	// Until the organisations are loaded, there is no environment to show.
 	currentEnv, err := m.tkview.GetCurrentEnvironment()
-	if err != nil {
+	if err != nil && !m.orgsLoaded {
 		return box.Render(err.Error())
 	}
 
-	t := tree.Root("(E)nvironments").
-		ItemStyleFunc(func(children tree.Children, i int) lipgloss.Style {
-			if children.At(i).Value() == currentEnv.Name {
-				return lipgloss.NewStyle().
-					Background(lipgloss.BrightBlue).
-					Foreground(lipgloss.White)
+	// Organisations that failed to load are still shown, so that they can be retried.
+	if _, ok := m.firstEnvironment(); !ok && !slices.ContainsFunc(m.orgs, func(o tkview.Organisation) bool { return o.Err != nil }) {
+		return box.Render("(E)nvironments\n\n" +
+			"No environments found.\n" +
+			"The API token may not have access to any organisations, or they may not have any environments yet.")
+	}
+
+	highlight := lipgloss.NewStyle().
+		Background(lipgloss.BrightBlue).
+		Foreground(lipgloss.White)
+
+	title := "(E)nvironments"
+	if m.envFilter.shown() {
+		title += " | " + m.envFilter.input.View()
+	}
+
+	orgs := m.visibleOrgs()
+
+	// Organisations are highlighted by index, as an organisation and an environment may share a name.
+	t := tree.Root(title).
+		ItemStyleFunc(func(_ tree.Children, i int) lipgloss.Style {
+			if m.orgCursor != "" && orgs[i].ID == m.orgCursor {
+				return highlight
 			}
 
 			return lipgloss.NewStyle()
 		})
 
-	for _, org := range m.orgs {
-		orgTree := tree.Root(org.Name)
+	for _, org := range orgs {
+		orgTree := tree.Root(org.Name).
+			ItemStyleFunc(func(_ tree.Children, i int) lipgloss.Style {
+				if m.orgCursor == "" && i < len(org.Envs) && org.Envs[i].ID == currentEnv.ID {
+					return highlight
+				}
+
+				return lipgloss.NewStyle()
+			})
+
+		if _, collapsed := m.collapsedOrgs[org.ID]; !collapsed {
+			for _, e := range org.Envs {
+				orgTree.Child(e.Name)
+			}
+		}
 
-		for _, e := range org.Envs {
-			orgTree.Child(e.Name)
+		switch {
+		case org.Err != nil:
+			orgTree.Child(lipgloss.NewStyle().Foreground(lipgloss.Red).Render("(failed to load, (enter) to retry)"))
+		case len(org.Envs) == 0:
+			orgTree.Child(lipgloss.NewStyle().Faint(true).Render("(no environments)"))
 		}
 
 		t.Child(orgTree)
@@ -86,7 +139,7 @@ func (m Model) renderAgents() string {
 	}
 
 	for _, agent := range m.agents {
-		t.Row(agent.Name, agent.Type, agent.Version, renderTime(agent.LastSeen))
+		t.Row(agent.Name, agent.Type, agent.Version, m.renderTime(agent.LastSeen))
 	}
 
 	m.padAgentTable(t)
@@ -104,54 +157,72 @@ func (m Model) padAgentTable(t *table.Table) {
 func (m Model) renderWorkflows() string {
 	box := lipgloss.NewStyle().
 		Border(lipgloss.NormalBorder(), true, true, true, true).
-		Height(m.height - m.topBoxHeight).
+		Height(m.bottomBoxHeight()).
 		Width(m.width)
 
 	if m.focused == viewWorkflows {
 		box = box.BorderStyle(lipgloss.DoubleBorder())
 	}
 
-	currentWorkflow, err := m.tkview.GetCurrentWorkflow()
-	if err != nil {
-		return box.Render(err.Error())
+	if _, ok := m.firstEnvironment(); m.orgsLoaded && !ok {
+		return box.Render("(W)orkflows\n\nNo environment to show the workflows of.")
 	}
 
-	title := "(W)orkflows"
-	if m.focused == viewWorkflows {
-		title += " | (s)tart"
+	// A newly created environment has no workflows to show, once they have been looked for.
+	if len(m.workflows) == 0 && m.workflowsLoaded {
+		return box.Render("(W)orkflows\n\nNo workflows found in this environment.")
 	}
 
-	t := tree.Root(title).
-		ItemStyleFunc(func(children tree.Children, i int) lipgloss.Style {
-			if children.At(i).Value() == m.renderWorkflow(currentWorkflow) {
-				return lipgloss.NewStyle().
-					Background(lipgloss.BrightBlue).
-					Foreground(lipgloss.White)
-			}
+	if _, err := m.tkview.GetCurrentWorkflow(); err != nil {
+		return box.Render(err.Error())
+	}
 
-			return lipgloss.NewStyle()
-		})
+	currentExecution, err := m.tkview.GetCurrentExecution()
+	executionSelected := err == nil
+
+	// The details of a selected execution are shown beside the tree, when there is room for both.
+	width := m.width
+	details := ""
 
-	for _, workflow := range m.workflows {
-		workflowTree := tree.Root(m.renderWorkflow(workflow))
+	if executionSelected && m.width >= uiDetailsBoxMinTreeWidth+uiDetailsBoxWidth {
+		width = m.width - uiDetailsBoxWidth
+		box = box.Width(width)
+		details = m.renderExecutionDetails(currentExecution)
+	}
 
-		_, expanded := m.expandedWorkflows[workflow.ID]
+	// Stale workflows are shown whilst they load again, but were not refreshed just now.
+	refreshed := "refreshed " + m.lastRefresh.Format(time.TimeOnly)
+	if m.revalidating {
+		refreshed = "refreshing"
+	}
 
-		if expanded {
-			for _, execution := range workflow.Executions {
-				executionTree := tree.Root(m.renderExecution(execution))
-				workflowTree.Child(executionTree)
-			}
-		}
+	title := fmt.Sprintf("(W)orkflows | %s | (r)efresh | (o)rder by %s", refreshed, m.sortMode)
 
-		t.Child(workflowTree)
+	if m.agentTimedOut {
+		title += " | " + lipgloss.NewStyle().Foreground(lipgloss.Red).Render("agent timed out")
 	}
 
-	return box.Render(t.String())
+	if m.workflowFilter.shown() {
+		title += " | " + m.workflowFilter.input.View()
+	}
+
+	switch {
+	case m.confirmAbort != "":
+		title += fmt.Sprintf(" | abort %s? (y/n)", m.confirmAbort)
+	case m.focused == viewWorkflows && executionSelected:
+		title += " | (s)tart | (x) abort | (l)ogs"
+	case m.focused == viewWorkflows:
+		title += " | (s)tart"
+	}
+
+	// Leave out the borders.
+	viewport := m.renderWorkflowViewport(width - 2)
+
+	return lipgloss.JoinHorizontal(0, box.Render(title+"\n"+viewport), details)
 }
 
-func (m Model) renderWorkflow(workflow tkview.Workflow) string {
-	t := renderTime(workflow.LastExecutionAt)
+func (m Model) renderWorkflow(wf tkview.Workflow) string {
+	t := m.renderTime(wf.LastExecutionAt)
 
 	timePadding := ""
 	if len(t) < len(time.RFC822) {
@@ -159,17 +230,17 @@ func (m Model) renderWorkflow(workflow tkview.Workflow) string {
 	}
 
 	return fmt.Sprintf("%s\t%s%s\t%s",
-		renderStatus(workflow.LastExecutionStatus),
+		renderStatus(wf.LastExecutionStatus),
 		timePadding,
 		t,
-		workflow.Name,
+		wf.Name,
 	)
 }
 
-func renderTime(t time.Time) string {
+func (m Model) renderTime(t time.Time) string {
 	format := time.RFC822
 	// If time is today, only show the time.
-	if t.Format(time.DateOnly) == time.Now().Format(time.DateOnly) {
+	if t.Format(time.DateOnly) == m.now().Format(time.DateOnly) {
 		format = "15:04 MST"
 	}
 
@@ -206,7 +277,7 @@ func renderStatus(s string) string {
 }
 
 func (m Model) renderExecution(execution tkview.Execution) string {
-	t := renderTime(execution.StartedAt)
+	t := m.renderTime(execution.StartedAt)
 
 	timePadding := ""
 	if len(t) < len(time.RFC822) {
@@ -220,3 +291,98 @@ func (m Model) renderExecution(execution tkview.Execution) string {
 		execution.Name,
 	)
 }
+
+// addSteps adds the passed steps, and any nested steps, as children of the passed tree.
+func (m Model) addSteps(t *tree.Tree, steps []workflow.Step) {
+	for _, step := range steps {
+		stepTree := tree.Root(m.renderStep(step))
+		m.addSteps(stepTree, step.Steps)
+		t.Child(stepTree)
+	}
+}
+
+func (m Model) renderStep(step workflow.Step) string {
+	duration := ""
+	if step.Duration > 0 {
+		duration = step.Duration.Round(10 * time.Millisecond).String()
+	}
+
+	return fmt.Sprintf("%s\t%s\t%s",
+		renderStepStatus(step.Status),
+		step.Name,
+		duration,
+	)
+}
+
+func renderStepStatus(s string) string {
+	status := "❓"
+
+	switch testkube.TestWorkflowStepStatus(s) {
+	case testkube.QUEUED_TestWorkflowStepStatus:
+		status = "🚶"
+	case testkube.RUNNING_TestWorkflowStepStatus:
+		status = "🔄"
+	case testkube.PAUSED_TestWorkflowStepStatus:
+		status = "⏸️"
+	case testkube.ABORTED_TestWorkflowStepStatus:
+		status = "🛑"
+	case testkube.SKIPPED_TestWorkflowStepStatus:
+		status = "⏭️"
+	case testkube.TIMEOUT_TestWorkflowStepStatus:
+		status = "⌛"
+	case testkube.PASSED_TestWorkflowStepStatus:
+		status = "✅"
+	case testkube.FAILED_TestWorkflowStepStatus:
+		status = "❌"
+	}
+
+	return status
+}
+
+func (m Model) renderErrors() string {
+	box := lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), true, true, true, true).
+		Height(m.errorBoxHeight).
+		MaxHeight(m.errorBoxHeight).
+		Width(m.width)
+
+	if len(m.errs) == 0 {
+		return box.Render("Errors | none")
+	}
+
+	box = box.BorderForeground(lipgloss.Red)
+
+	title := fmt.Sprintf("Errors %d/%d | (<) older | (>) newer | (C)lear", m.errIndex+1, len(m.errs))
+
+	lines := []string{title}
+
+	if hint := errorHint(m.errs[m.errIndex]); hint != "" {
+		lines = append(lines, lipgloss.NewStyle().Bold(true).Render(hint))
+	}
+
+	for i, e := range errorChain(m.errs[m.errIndex]) {
+		lines = append(lines, strings.Repeat("  ", i)+e)
+	}
+
+	return box.Render(strings.Join(lines, "\n"))
+}
+
+// errorChain splits a wrapped error into the message added at each level of wrapping,
+// starting with the outermost.
+func errorChain(err error) []string {
+	var chain []string
+
+	for err != nil {
+		msg := err.Error()
+
+		next := errors.Unwrap(err)
+		if next != nil {
+			msg = strings.TrimSuffix(msg, ": "+next.Error())
+		}
+
+		chain = append(chain, msg)
+		err = next
+	}
+
+	return chain
+}
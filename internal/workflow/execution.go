@@ -1,8 +1,10 @@
 package workflow
 
 import (
+	"context"
 	"time"
 
+	"tkview/internal/agent"
 	"tkview/internal/environment"
 	"tkview/internal/organisation"
 )
@@ -12,13 +14,38 @@ type ExecutionID string
 
 // Execution is a tkview representation of a test workflow execution.
 type Execution struct {
-	ID        ExecutionID
-	Name      string
+	ID   ExecutionID
+	Name string
+	// Number counts the executions of the workflow, starting from 1.
+	Number    int
 	StartedAt time.Time
-	Status    string
+	// FinishedAt and Duration are zero whilst the execution is still running.
+	FinishedAt time.Time
+	Duration   time.Duration
+	Status     string
+	// RunnerID is the agent that ran the execution, it is empty if unknown.
+	RunnerID agent.ID
+	// Trigger is what started the execution, such as cron, manual, or API, it is empty if unknown.
+	Trigger string
+	Tags    map[string]string
+	// Config is the configuration the execution was started with, with sensitive values masked.
+	Config map[string]string
 }
 
-// ExecutionLister should return test workflow executions from a datasource.
+// Page selects part of a long list, such as the executions of a workflow.
+type Page struct {
+	// Number counts from 0, which is the most recent page.
+	Number int
+	// Size is the most items in each page.
+	Size int
+}
+
+// ExecutionLister should return a page of test workflow executions, most recent first, from a datasource.
 type ExecutionLister interface {
-	ListExecutions(orgID organisation.ID, envID environment.ID, id ID) ([]Execution, error)
+	ListExecutions(ctx context.Context, orgID organisation.ID, envID environment.ID, id ID, page Page) ([]Execution, error)
+}
+
+// Aborter should abort a running test workflow execution at a datasource.
+type Aborter interface {
+	AbortExecution(ctx context.Context, orgID organisation.ID, envID environment.ID, id ExecutionID) error
 }
@@ -0,0 +1,22 @@
+package workflow
+
+import (
+	"context"
+
+	"tkview/internal/environment"
+	"tkview/internal/organisation"
+)
+
+// LogLine is a single line of output from a test workflow execution.
+type LogLine struct {
+	// StepID is the step that produced the line, if known.
+	StepID StepID
+	Text   string
+}
+
+// LogStreamer should stream the output of a test workflow execution from a datasource.
+// Lines should be sent until the output is exhausted or the context is cancelled.
+// If the execution is still running, the stream should follow its output until it finishes.
+type LogStreamer interface {
+	StreamLogs(ctx context.Context, orgID organisation.ID, envID environment.ID, id ExecutionID, lines chan<- LogLine) error
+}
@@ -0,0 +1,31 @@
+package workflow
+
+import (
+	"context"
+	"time"
+
+	"tkview/internal/environment"
+	"tkview/internal/organisation"
+)
+
+// Metrics summarise the recent executions of a workflow.
+type Metrics struct {
+	// Executions is the number of recent executions that the metrics cover, Failed of which failed.
+	Executions      int
+	Failed          int
+	AverageDuration time.Duration
+}
+
+// FailureRate returns the fraction of the recent executions that failed, from 0 to 1.
+func (m Metrics) FailureRate() float64 {
+	if m.Executions == 0 {
+		return 0
+	}
+
+	return float64(m.Failed) / float64(m.Executions)
+}
+
+// MetricsGetter should return the metrics of a workflow from a datasource.
+type MetricsGetter interface {
+	GetMetrics(ctx context.Context, orgID organisation.ID, envID environment.ID, id ID) (Metrics, error)
+}
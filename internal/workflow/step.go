@@ -0,0 +1,28 @@
+package workflow
+
+import (
+	"context"
+	"time"
+
+	"tkview/internal/environment"
+	"tkview/internal/organisation"
+)
+
+// StepID is the unique identifier of a step within a test workflow execution.
+type StepID string
+
+// Step is a tkview representation of a step within a test workflow execution.
+// Steps may be nested, for example when a step is a group of other steps.
+type Step struct {
+	ID        StepID
+	Name      string
+	Status    string
+	StartedAt time.Time
+	Duration  time.Duration
+	Steps     []Step
+}
+
+// StepLister should return the steps of a test workflow execution from a datasource.
+type StepLister interface {
+	ListSteps(ctx context.Context, orgID organisation.ID, envID environment.ID, id ExecutionID) ([]Step, error)
+}
@@ -2,6 +2,7 @@
 package workflow
 
 import (
+	"context"
 	"time"
 
 	"tkview/internal/environment"
@@ -17,9 +18,15 @@ type Workflow struct {
 	Name                string
 	LastExecutionAt     time.Time
 	LastExecutionStatus string
+	Labels              map[string]string
 }
 
 // Lister should return workflows from a datasource.
 type Lister interface {
-	ListWorkflows(orgID organisation.ID, envID environment.ID) ([]Workflow, error)
+	ListWorkflows(ctx context.Context, orgID organisation.ID, envID environment.ID) ([]Workflow, error)
+}
+
+// Starter should start a new execution of a workflow at a datasource.
+type Starter interface {
+	StartExecution(ctx context.Context, orgID organisation.ID, envID environment.ID, id ID) (Execution, error)
 }
@@ -2,38 +2,332 @@
 package main
 
 import (
+	"context"
+	"crypto/sha256"
+	"errors"
 	"flag"
+	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"tkview/internal/config"
+	"tkview/internal/fake"
+	"tkview/internal/list"
+	"tkview/internal/state"
 	"tkview/internal/testkube"
 	"tkview/internal/tkview"
+	"tkview/internal/token"
 	"tkview/internal/ui"
 
 	"github.com/charmbracelet/bubbletea/v2"
 )
 
+// tokenEnv is the environment variable that the API token is read from when not given by a flag.
+const tokenEnv = "TKVIEW_TOKEN"
+
+// demoToken is given to the fake testkube API, which accepts any token.
+const demoToken = "demo"
+
+// options are the values of the command line flags.
+type options struct {
+	token        string
+	tokenFile    string
+	url          string
+	refresh      time.Duration
+	configPath   string
+	statePath    string
+	cacheDir     string
+	profileName  string
+	demo         bool
+	demoFixtures string
+	// args are what is left after the flags, which select a list command rather than the UI.
+	args []string
+	// set holds the names of the flags that were explicitly set.
+	set map[string]bool
+}
+
 func main() {
-	var (
-		token string
-		url   string
-	)
+	opts := parseFlags()
 
-	flag.StringVar(&token, "token", "", "API Token")
-	flag.StringVar(&url, "url", "http://localhost:8099", "URL")
-	flag.Parse()
+	run := runConfigured
+	if opts.demo {
+		run = runDemo
+	}
 
-	if token == "" {
-		log.Println("You must provide an API Token")
+	if err := run(opts); err != nil {
+		log.Println(err)
 		os.Exit(1)
 	}
+}
+
+func parseFlags() options {
+	var opts options
+
+	// If there is no user config dir, then there is no default config file, or state file, either.
+	defaultConfigPath, _ := config.DefaultPath()
+	defaultStatePath, _ := state.DefaultPath()
+	defaultCacheDir, _ := tkview.DefaultCacheDir()
+
+	flag.StringVar(&opts.token, "token", "", "API Token, prefer -token-file or "+tokenEnv+" to keep it out of shell history")
+	flag.StringVar(&opts.tokenFile, "token-file", "", "File containing the API Token")
+	flag.StringVar(&opts.url, "url", "http://localhost:8099", "URL")
+	flag.DurationVar(&opts.refresh, "refresh", 30*time.Second, "Interval between automatic refreshes, 0 to disable")
+	flag.StringVar(&opts.configPath, "config", defaultConfigPath, "Configuration file")
+	flag.StringVar(&opts.statePath, "state", defaultStatePath, "File remembering what was last looked at, empty to forget")
+	flag.StringVar(&opts.cacheDir, "cache", defaultCacheDir, "Directory caching what was loaded between runs, empty to only cache whilst running")
+	flag.StringVar(&opts.profileName, "profile", "", "Configuration profile, defaults to the configured default profile")
+	flag.BoolVar(&opts.demo, "demo", false, "Use a fake testkube API with demonstration data, no token or configuration is required")
+	flag.StringVar(&opts.demoFixtures, "demo-fixtures", "", "Directory of fixture files for the -demo fake testkube API, defaults to the built-in data")
+	flag.Usage = func() {
+		out := flag.CommandLine.Output()
+		_, _ = fmt.Fprintf(out, "Usage: %s [flags] [%s [list flags]]\n", os.Args[0], strings.Join(list.Commands, "|"))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	opts.args = flag.Args()
+
+	// Flags that are explicitly set take precedence over the selected profile.
+	opts.set = make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		opts.set[f.Name] = true
+	})
+
+	return opts
+}
+
+// runConfigured runs tkview against the testkube API described by the flags, and the configuration file.
+func runConfigured(opts options) error {
+	cfg, err := config.Load(opts.configPath)
+	if err != nil {
+		return err
+	}
+
+	url := opts.url
+	refresh := opts.refresh
+	src := flagTokenSource(opts.set, opts.token, opts.tokenFile)
+
+	var profile config.Profile
+
+	if opts.profileName != "" || cfg.DefaultProfile != "" {
+		profile, err = cfg.Profile(opts.profileName)
+		if err != nil {
+			return err
+		}
+
+		if !opts.set["url"] && profile.URL != "" {
+			url = profile.URL
+		}
+
+		if src == nil {
+			src, err = profile.Token.Source()
+			if err != nil {
+				return fmt.Errorf("token for profile %q: %w", profile.Name, err)
+			}
+		}
+
+		if !opts.set["refresh"] && profile.RefreshInterval != 0 {
+			refresh = time.Duration(profile.RefreshInterval)
+		}
+	}
+
+	if src == nil {
+		return errNoToken
+	}
+
+	// Check the token can be found upfront, rather than on the first request.
+	if _, err := src.Token(context.Background()); err != nil {
+		return fmt.Errorf("get API Token: %w", err)
+	}
+
+	// Scripts want what is there now, rather than what was cached.
+	if len(opts.args) > 0 {
+		tk := tkview.New(newClient(url, src, profile.Retry))
+
+		return runList(tk, opts.args, list.Selection{Organisation: profile.Organisation, Environment: profile.Environment})
+	}
+
+	caches := &cacheSet{dir: opts.cacheDir}
+
+	tk := tkview.New(caches.add(newClient(url, src, profile.Retry), state.Key(profile.Name, url)))
+	m := ui.NewModel(tk, refresh).WithProfiles(profile.Name, uiProfiles(cfg, refresh, caches))
+
+	if opts.statePath != "" {
+		m = m.WithSessions(state.NewStore(opts.statePath), state.Key(profile.Name, url))
+	}
+
+	return runModel(m, caches)
+}
+
+// runDemo runs tkview against an in-process fake testkube API.
+func runDemo(opts options) (err error) {
+	fixtures := fake.Demo()
+	if opts.demoFixtures != "" {
+		fixtures = os.DirFS(opts.demoFixtures)
+	}
+
+	srv, err := fake.New(fixtures)
+	if err != nil {
+		return fmt.Errorf("create demo api: %w", err)
+	}
+
+	url, stop, err := fake.Start(srv)
+	if err != nil {
+		return fmt.Errorf("start demo api: %w", err)
+	}
+
+	defer func() {
+		if stopErr := stop(); stopErr != nil && err == nil {
+			err = fmt.Errorf("stop demo api: %w", stopErr)
+		}
+	}()
+
+	if len(opts.args) > 0 {
+		return runList(tkview.New(testkube.New(url, token.Static(demoToken))), opts.args, list.Selection{})
+	}
+
+	// The demo data is made up afresh each run, so is only cached whilst running.
+	caches := &cacheSet{}
+	tk := tkview.New(caches.add(testkube.New(url, token.Static(demoToken)), ""))
+
+	return runModel(ui.NewModel(tk, opts.refresh), caches)
+}
+
+// runList prints the list selected by args, rather than running the UI.
+func runList(tk *tkview.TKView, args []string, defaults list.Selection) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := list.Run(ctx, os.Stdout, os.Stderr, tk, args, defaults)
+
+	// The help has already been printed, which is all that was asked for.
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
 
-	client := testkube.New(url, token)
-	tk := tkview.New(client)
+	return err //nolint:wrapcheck // The list errors say what could not be listed.
+}
 
-	m := ui.NewModel(tk)
+func runModel(m ui.Model, caches *cacheSet) error {
+	final, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	if err != nil {
+		return fmt.Errorf("run: %w", err)
+	}
 
-	if _, err := tea.NewProgram(m, tea.WithAltScreen()).Run(); err != nil {
-		panic(err)
+	// Remember what was being looked at, for the next run to carry on from.
+	if m, ok := final.(ui.Model); ok {
+		if err := m.SaveSession(); err != nil {
+			return err
+		}
 	}
+
+	return caches.save()
+}
+
+// cacheSet creates the caches of every profile connected to, so that they can all be saved on exit.
+type cacheSet struct {
+	// dir is where the cache files are kept, if it is empty, nothing is cached between runs.
+	dir    string
+	caches []*tkview.Cache
+}
+
+// add creates a cache in front of the passed client, keeping it in a cache file named after the passed key.
+func (s *cacheSet) add(client testkube.Client, key string) *tkview.Cache {
+	path := ""
+	if s.dir != "" {
+		// Keys are URLs, which are not safe file names.
+		path = filepath.Join(s.dir, fmt.Sprintf("%x.json", sha256.Sum256([]byte(key))))
+	}
+
+	cache := tkview.NewCache(client, tkview.DefaultCacheTTLs, path)
+	s.caches = append(s.caches, cache)
+
+	return cache
+}
+
+// save saves every cache, returning the first error.
+func (s *cacheSet) save() error {
+	for _, c := range s.caches {
+		if err := c.Save(); err != nil {
+			return fmt.Errorf("save cache: %w", err)
+		}
+	}
+
+	return nil
+}
+
+var errNoToken = errors.New("you must provide an API Token")
+
+// flagTokenSource returns the token source requested by flags, or the environment, if any.
+// The most explicit token source wins.
+func flagTokenSource(set map[string]bool, tokenFlag, tokenFile string) token.Source {
+	switch {
+	case set["token"]:
+		return token.Static(tokenFlag)
+	case set["token-file"]:
+		return token.File(tokenFile)
+	case os.Getenv(tokenEnv) != "":
+		return token.Env(tokenEnv)
+	default:
+		return nil
+	}
+}
+
+// newClient creates a testkube API client, overriding the default retry policy with any configured parts of retry.
+func newClient(url string, src token.Source, retry *config.Retry) testkube.Client {
+	client := testkube.New(url, src)
+	if retry == nil {
+		return client
+	}
+
+	policy := testkube.DefaultRetryPolicy
+
+	if retry.MaxAttempts != 0 {
+		policy.MaxAttempts = retry.MaxAttempts
+	}
+
+	if retry.BaseDelay != 0 {
+		policy.BaseDelay = time.Duration(retry.BaseDelay)
+	}
+
+	if retry.MaxDelay != 0 {
+		policy.MaxDelay = time.Duration(retry.MaxDelay)
+	}
+
+	return client.WithRetryPolicy(policy)
+}
+
+// uiProfiles converts the configured profiles to profiles that can be switched to from within the UI.
+// Profiles without a refresh interval use the passed default refresh interval, and each is cached within caches.
+func uiProfiles(cfg config.Config, defaultRefresh time.Duration, caches *cacheSet) []ui.Profile {
+	profiles := make([]ui.Profile, 0, len(cfg.Profiles))
+
+	for _, p := range cfg.Profiles {
+		refresh := defaultRefresh
+		if p.RefreshInterval != 0 {
+			refresh = time.Duration(p.RefreshInterval)
+		}
+
+		profiles = append(profiles, ui.Profile{
+			Name: p.Name,
+			Connect: func() (*tkview.TKView, error) {
+				src, err := p.Token.Source()
+				if err != nil {
+					return nil, fmt.Errorf("token: %w", err)
+				}
+
+				return tkview.New(caches.add(newClient(p.URL, src, p.Retry), state.Key(p.Name, p.URL))), nil
+			},
+			RefreshInterval: refresh,
+			Organisation:    p.Organisation,
+			Environment:     p.Environment,
+			SessionKey:      state.Key(p.Name, p.URL),
+		})
+	}
+
+	return profiles
 }